@@ -0,0 +1,265 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command mymodel generates the SQL schema and the Go repositories for the model structs of a package.
+//
+// A struct is a model if its declaration is annotated with a "//mymodel:model" comment.
+// Types referenced by models are included automatically and need not be annotated.
+//
+//	//mymodel:model
+//	type User struct {
+//		Id   uint32 `primaryKey:"" autoIncrement:""`
+//		Name string `width:"64"`
+//	}
+//
+// It is intended to be run from a go:generate directive in the package declaring the models:
+//
+//	//go:generate mymodel -sql schema.sql -go models_gen.go
+//
+// With -ddl, it instead writes the model structs for the CREATE TABLE statements of an existing database to the -go file:
+//
+//	mymodel -ddl dump.sql -package models -go models.go
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	myModel "github.com/SOF3/my-model"
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	sqlPath       = flag.String("sql", "schema.sql", "file to write the CREATE TABLE statements to")
+	goPath        = flag.String("go", "mymodel_gen.go", "file to write the Go repositories to")
+	snapshotPath  = flag.String("snapshot", "", "file to write the schema snapshot to, if any")
+	previousPath  = flag.String("previous", "", "schema snapshot of the deployed database, to generate a migration from")
+	migrationPath = flag.String("migration", "", "file to write the migration from -previous to, if any")
+	indent        = flag.String("indent", "\t", "indentation of the generated SQL")
+	ddlPath       = flag.String("ddl", "", "CREATE TABLE statements to write the model structs for, instead of generating from the models")
+	packageName   = flag.String("package", "models", "package of the model structs written with -ddl")
+	dialectName   = flag.String("dialect", "mysql", "database to generate the SQL and Go code for: mysql, postgres or sqlite")
+	naming        = flag.String("naming", "", "how tables and columns are named: empty to use the names of the types and fields, or snake for snake_case")
+	tablePrefix   = flag.String("tablePrefix", "", "prefix of the table names with -naming snake")
+	plural        = flag.Bool("plural", false, "pluralize the table names with -naming snake")
+)
+
+var dialects = map[string]myModel.Dialect{
+	"mysql":    myModel.MysqlDialect{},
+	"postgres": myModel.PostgresDialect{},
+	"sqlite":   myModel.SqliteDialect{},
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: mymodel [flags] [package]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	pattern := "."
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	} else if flag.NArg() == 1 {
+		pattern = flag.Arg(0)
+	}
+
+	run := run
+	if *ddlPath != "" {
+		if flag.NArg() > 0 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		run = runDdl
+	}
+	if err := run(pattern); err != nil {
+		fmt.Fprintln(os.Stderr, "mymodel:", err)
+		os.Exit(1)
+	}
+}
+
+func run(pattern string) error {
+	dialect, exists := dialects[*dialectName]
+	if !exists {
+		return fmt.Errorf("unknown dialect %q", *dialectName)
+	}
+
+	var namingStrategy myModel.NamingStrategy
+	switch *naming {
+	case "":
+		if *tablePrefix != "" || *plural {
+			return errors.New("-tablePrefix and -plural require -naming snake")
+		}
+	case "snake":
+		namingStrategy = myModel.SnakeCaseNaming{TablePrefix: *tablePrefix, Plural: *plural}
+	default:
+		return fmt.Errorf("unknown naming %q", *naming)
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+	}, pattern)
+	if err != nil {
+		return err
+	}
+	if len(pkgs) != 1 {
+		return fmt.Errorf("%s matches %d packages, expected exactly one", pattern, len(pkgs))
+	}
+	pkg := pkgs[0]
+	if pkg.Types == nil {
+		return fmt.Errorf("cannot load package %s", pattern)
+	}
+	for _, err := range pkg.Errors {
+		// the package may contain generated code that is outdated; the models are usable as long as they type-check
+		fmt.Fprintln(os.Stderr, "mymodel: warning:", err)
+	}
+
+	models := findModels(pkg)
+	if len(models) == 0 {
+		return fmt.Errorf("no struct in %s is annotated with %s", pkg.PkgPath, myModel.ModelDirective)
+	}
+
+	sqlBuffer, goBuffer, snapshotBuffer, migrationBuffer := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+	config := myModel.GeneratorConfig{
+		Package:   pkg.Name,
+		Indent:    *indent,
+		Eol:       "\n",
+		SqlStream: sqlBuffer,
+		GoStream:  goBuffer,
+
+		DiagnosticStream: os.Stderr,
+		Dialect:          dialect,
+		Naming:           namingStrategy,
+	}
+	if *snapshotPath != "" {
+		config.SnapshotStream = snapshotBuffer
+	}
+	if *previousPath != "" {
+		if *migrationPath == "" {
+			return errors.New("-previous requires -migration")
+		}
+		file, err := os.Open(*previousPath)
+		if err != nil {
+			return err
+		}
+		config.Previous, err = myModel.LoadSchema(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("cannot load %s: %v", *previousPath, err)
+		}
+		config.MigrationStream = migrationBuffer
+	}
+
+	if err := myModel.GenerateTypes(config, models); err != nil {
+		if diagnostics, ok := err.(myModel.Diagnostics); ok {
+			fmt.Fprintln(os.Stderr, diagnostics.Error())
+			return fmt.Errorf("%d problems found in the models", len(diagnostics))
+		}
+		return err
+	}
+
+	// files are only written after generation succeeded, so that a failed run does not destroy the previous output
+	outputs := []struct {
+		path   string
+		buffer *bytes.Buffer
+	}{
+		{*sqlPath, sqlBuffer},
+		{*goPath, goBuffer},
+		{*snapshotPath, snapshotBuffer},
+		{*migrationPath, migrationBuffer},
+	}
+	for _, output := range outputs {
+		if output.path == "" {
+			continue
+		}
+		if err := ioutil.WriteFile(output.path, output.buffer.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runDdl writes the model structs for the tables in the file at -ddl.
+func runDdl(string) error {
+	file, err := os.Open(*ddlPath)
+	if err != nil {
+		return err
+	}
+	schema, err := myModel.ParseDdl(file)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("cannot parse %s: %v", *ddlPath, err)
+	}
+	if diagnostics := schema.Diagnostics(); len(diagnostics) > 0 {
+		fmt.Fprintln(os.Stderr, diagnostics.Error())
+	}
+
+	goBuffer := &bytes.Buffer{}
+	if err := schema.OutputModels(myModel.GeneratorConfig{Package: *packageName, GoStream: goBuffer}); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(*goPath, goBuffer.Bytes(), 0644)
+}
+
+// findModels returns the types annotated with myModel.ModelDirective, in declaration order.
+func findModels(pkg *packages.Package) []myModel.TypeInfo {
+	models := []myModel.TypeInfo{}
+	source := myModel.NewSourcePackage(pkg.Fset, pkg.Syntax...)
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				doc := typeSpec.Doc
+				if doc == nil && len(genDecl.Specs) == 1 {
+					doc = genDecl.Doc
+				}
+				if !hasDirective(doc) {
+					continue
+				}
+				if object := pkg.Types.Scope().Lookup(typeSpec.Name.Name); object != nil {
+					models = append(models, source.Type(object.Type()))
+				}
+			}
+		}
+	}
+	return models
+}
+
+func hasDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, comment := range doc.List {
+		if strings.TrimSpace(comment.Text) == myModel.ModelDirective {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hoop33/go-elvis"
+	"strconv"
+	"strings"
+)
+
+// columnDefault returns the DEFAULT expression of field in MySQL syntax, from the value of a default tag or a DDL default.
+// Unless quoted is true, value may be NULL or one of the expressions CURRENT_TIMESTAMP and UUID();
+// otherwise it is a literal, which is checked against the type of the column.
+func columnDefault(field *MysqlField, value string, quoted bool) (string, error) {
+	if !quoted {
+		switch strings.ToUpper(value) {
+		case "NULL":
+			if !field.Nullable {
+				return "", errors.New("NOT NULL columns cannot default to NULL")
+			}
+			return "NULL", nil
+		case "CURRENT_TIMESTAMP":
+			if field.Type != "TIMESTAMP" {
+				return "", errors.New("only TIMESTAMP columns can default to CURRENT_TIMESTAMP")
+			}
+			return "CURRENT_TIMESTAMP", nil
+		case "UUID()":
+			var width int
+			if _, err := fmt.Sscanf(strings.TrimPrefix(field.Type, "VAR"), "CHAR(%d)", &width); err != nil || width < 36 {
+				return "", errors.New("only CHAR and VARCHAR columns of at least 36 characters can default to UUID()")
+			}
+			return "UUID()", nil
+		}
+	}
+
+	switch {
+	case field.Type == "BOOL":
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid boolean default %q", value)
+		}
+		return elvis.Ternary(boolean, "TRUE", "FALSE").(string), nil
+	case strings.Contains(field.Type, "INT"):
+		var err error
+		if strings.HasSuffix(field.Type, " UNSIGNED") {
+			_, err = strconv.ParseUint(value, 10, 64)
+		} else {
+			_, err = strconv.ParseInt(value, 10, 64)
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid integer default %q", value)
+		}
+		return value, nil
+	case field.Type == "FLOAT" || field.Type == "DOUBLE" || strings.HasPrefix(field.Type, "DECIMAL("):
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", fmt.Errorf("invalid numeric default %q", value)
+		}
+		return value, nil
+	case len(field.Values) > 0:
+		members := []string{value}
+		if isSetType(field.Type) && value != "" {
+			members = strings.Split(value, ",")
+		}
+	members:
+		for _, member := range members {
+			for _, allowed := range field.Values {
+				if member == allowed {
+					continue members
+				}
+			}
+			if !(isSetType(field.Type) && member == "") {
+				return "", fmt.Errorf("default %q is not one of the values of the column", member)
+			}
+		}
+	case strings.HasSuffix(field.Type, "TEXT") || strings.HasSuffix(field.Type, "BLOB") || field.Type == "JSON":
+		return "", fmt.Errorf("%s columns cannot have a literal default", field.Type)
+	}
+	return quoteSqlString(value), nil
+}
+
+// tagDefault returns the DEFAULT expression of field declared by a default tag.
+// A value in single quotes is always a string literal, such as 'NULL' for the string NULL or two quotes for an empty string.
+func tagDefault(field *MysqlField, value string) (string, error) {
+	if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		return columnDefault(field, strings.Replace(value[1:len(value)-1], "''", "'", -1), true)
+	}
+	if value == "" {
+		return "", errors.New("the default tag must not be empty; declare an empty string as ''")
+	}
+	return columnDefault(field, value, false)
+}
+
+// columnOnUpdate returns the ON UPDATE expression of field from the value of an onUpdate tag or a DDL ON UPDATE clause.
+func columnOnUpdate(field *MysqlField, value string) (string, error) {
+	if !strings.EqualFold(value, "CURRENT_TIMESTAMP") {
+		return "", fmt.Errorf("unsupported ON UPDATE expression %q; only CURRENT_TIMESTAMP is supported", value)
+	}
+	if field.Type != "TIMESTAMP" {
+		return "", errors.New("only TIMESTAMP columns can be updated to CURRENT_TIMESTAMP")
+	}
+	return "CURRENT_TIMESTAMP", nil
+}
+
+// defaultTagValue returns the value of the default tag declaring the DEFAULT expression of a column, as understood by columnDefault.
+func defaultTagValue(expression string) string {
+	switch expression {
+	case "TRUE":
+		return "true"
+	case "FALSE":
+		return "false"
+	}
+	if !strings.HasPrefix(expression, "'") {
+		return expression // an expression or a number
+	}
+	value := strings.Replace(expression[1:len(expression)-1], "''", "'", -1)
+	switch strings.ToUpper(value) {
+	case "", "NULL", "CURRENT_TIMESTAMP", "UUID()":
+		return expression // a string that would be empty or read as an expression without the quotes
+	}
+	return value
+}
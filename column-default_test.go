@@ -0,0 +1,95 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTagDefault(t *testing.T) {
+	for _, test := range []struct {
+		field    MysqlField
+		tag      string
+		expected string // empty if an error is expected
+	}{
+		{MysqlField{Type: "INT SIGNED"}, "-3", "-3"},
+		{MysqlField{Type: "INT UNSIGNED"}, "-3", ""},
+		{MysqlField{Type: "BOOL"}, "true", "TRUE"},
+		{MysqlField{Type: "VARCHAR(16)"}, "it's", "'it''s'"},
+		{MysqlField{Type: "VARCHAR(16)"}, "''", "''"},
+		{MysqlField{Type: "VARCHAR(16)"}, "'NULL'", "'NULL'"},
+		{MysqlField{Type: "VARCHAR(16)"}, "NULL", ""},
+		{MysqlField{Type: "VARCHAR(16)", Nullable: true}, "NULL", "NULL"},
+		{MysqlField{Type: "TIMESTAMP"}, "CURRENT_TIMESTAMP", "CURRENT_TIMESTAMP"},
+		{MysqlField{Type: "DATE"}, "CURRENT_TIMESTAMP", ""},
+		{MysqlField{Type: "CHAR(36)"}, "UUID()", "UUID()"},
+		{MysqlField{Type: "ENUM('a','b')", Values: []string{"a", "b"}}, "c", ""},
+		{MysqlField{Type: "SET('a','b')", Values: []string{"a", "b"}}, "a,b", "'a,b'"},
+		{MysqlField{Type: "TEXT"}, "text", ""},
+	} {
+		expression, err := tagDefault(&test.field, test.tag)
+		if test.expected == "" && err == nil {
+			t.Errorf("%s default %q: expected an error, got %s", test.field.Type, test.tag, expression)
+		} else if test.expected != "" && expression != test.expected {
+			t.Errorf("%s default %q: expected %s, got %s (%v)", test.field.Type, test.tag, test.expected, expression, err)
+		}
+	}
+}
+
+const defaultModels = `package models
+
+type Token struct {
+	Id    string ` + "`primaryKey:\"\" fixed:\"\" width:\"36\" default:\"UUID()\" comment:\"the token\"`" + `
+	Owner *Token
+}
+`
+
+// TestForeignKeyColumnDefaults checks that the columns referencing a key do not copy its default, auto increment and comment.
+func TestForeignKeyColumnDefaults(t *testing.T) {
+	_, _, models := sourceModels(t, defaultModels, "Token")
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	owner := schema.Tables["Token"].FindField("Owner_Id")
+	if owner == nil || owner.Default != "" || owner.Comment != "" {
+		t.Errorf("expected Owner_Id to have no default and no comment, got %+v", owner)
+	}
+}
+
+func TestOutputGoDefaults(t *testing.T) {
+	_, _, models := sourceModels(t, repositoryModels, "User")
+	generated := &strings.Builder{}
+	if err := GenerateTypes(GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", SqlStream: &strings.Builder{}, GoStream: generated}, models); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	code := generated.String()
+	insert := code[strings.Index(code, "func (repo *UserRepository) Insert("):strings.Index(code, "func (repo *UserRepository) GetByPrimaryKey(")]
+	if !strings.Contains(insert, "if entity.Created != nil {") || !strings.Contains(insert, "columns := []string{\"`Name`\", \"`Email`\", \"`Updated`\",") {
+		t.Errorf("expected Insert to leave out Created when it is nil, got\n%s", insert)
+	}
+	if !strings.Contains(insert, "\"`Status`\"") || strings.Contains(insert, "isZero") {
+		t.Errorf("expected Insert to store Status even if it is zero, got\n%s", insert)
+	}
+	update := code[strings.Index(code, "func (repo *UserRepository) Update("):strings.Index(code, "func (repo *UserRepository) Delete(")]
+	if strings.Contains(update, "`Updated` = ?") {
+		t.Errorf("expected Update to leave Updated to its ON UPDATE clause, got\n%s", update)
+	}
+}
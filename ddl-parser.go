@@ -0,0 +1,938 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"fmt"
+	"github.com/hoop33/go-elvis"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ParseDdl builds a schema from the CREATE TABLE and CREATE INDEX statements of a MySQL script, such as the output of mysqldump,
+// and from the keys added by ALTER TABLE, such as the foreign keys closing reference cycles.
+//
+// Other statements and comments are skipped. Clauses that the schema cannot represent, such as the collations of columns
+// and CHECK constraints, are skipped with a warning in Schema.Diagnostics, like columns that cannot be represented exactly,
+// such as DATETIME columns. The names of foreign key constraints are kept.
+// The tables of the schema have no models, so the schema can be written with OutputModels or used as the previous schema of a migration,
+// but not with OutputGo.
+func ParseDdl(reader io.Reader) (*Schema, error) {
+	source, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := tokenizeDdl(string(source))
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &ddlParser{tokens: tokens, schema: &Schema{Tables: map[string]*MainTable{}, graphOutdated: true}}
+	for !parser.done() {
+		if parser.acceptWords("CREATE", "TABLE") {
+			err = parser.parseCreateTable()
+		} else if parser.acceptWords("CREATE", "INDEX") {
+			err = parser.parseCreateIndex(false)
+		} else if parser.acceptWords("CREATE", "UNIQUE", "INDEX") {
+			err = parser.parseCreateIndex(true)
+		} else if parser.acceptWords("ALTER", "TABLE") {
+			err = parser.parseAlterTable()
+		} else {
+			parser.skipStatement()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parser.schema, nil
+}
+
+var mysqlTypePattern = regexp.MustCompile(`^(\w+)(?:\(([0-9, ]*)\))?((?:\s+\w+)*)$`)
+
+type ddlTokenKind int
+
+const (
+	ddlWord       ddlTokenKind = iota // keyword or unquoted identifier
+	ddlIdentifier                     // identifier quoted with backticks
+	ddlString
+	ddlNumber
+	ddlSymbol
+)
+
+type ddlToken struct {
+	kind ddlTokenKind
+	text string // unquoted text of identifiers and strings
+	line int
+}
+
+func tokenizeDdl(source string) ([]ddlToken, error) {
+	tokens := []ddlToken{}
+	runes := []rune(source)
+	line := 1
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case unicode.IsSpace(c):
+			i++
+		case c == '#' || c == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			// conditional comments of mysqldump (/*!40101 ... */) only contain session settings and are skipped too
+			start := line
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated comment", start)
+			}
+			i += 2
+		case c == '`' || c == '\'' || c == '"':
+			start := line
+			text := []rune{}
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("line %d: unterminated quote %c", start, c)
+				}
+				if runes[i] == c {
+					if i+1 < len(runes) && runes[i+1] == c { // doubled quote
+						text = append(text, c)
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				if runes[i] == '\\' && c != '`' && i+1 < len(runes) {
+					i++
+				}
+				if runes[i] == '\n' {
+					line++
+				}
+				text = append(text, runes[i])
+				i++
+			}
+			kind := ddlString
+			if c == '`' {
+				kind = ddlIdentifier
+			}
+			tokens = append(tokens, ddlToken{kind, string(text), start})
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, ddlToken{ddlNumber, string(runes[start:i]), line})
+		case unicode.IsLetter(c) || c == '_' || c == '$':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$') {
+				i++
+			}
+			tokens = append(tokens, ddlToken{ddlWord, string(runes[start:i]), line})
+		default:
+			tokens = append(tokens, ddlToken{ddlSymbol, string(c), line})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+type ddlParser struct {
+	tokens []ddlToken
+	pos    int
+	schema *Schema
+}
+
+// warn records a difference between the declaration of a column and the schema, which is available from Schema.Diagnostics.
+func (parser *ddlParser) warn(line int, table *Table, column string, format string, args ...interface{}) {
+	parser.schema.diagnostics = append(parser.schema.diagnostics, &Diagnostic{
+		Severity: SeverityWarning,
+		Position: fmt.Sprintf("line %d", line),
+		Type:     table.Name,
+		Field:    column,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// ignored warns that the tokens from start up to the current token are skipped, unless there are no such tokens.
+func (parser *ddlParser) ignored(start int, table *Table, column string) {
+	if parser.pos > start {
+		parser.warn(parser.tokens[start].line, table, column, "%s is ignored, because the schema cannot represent it", parser.text(start, parser.pos))
+	}
+}
+
+// text returns the tokens from start up to end as they are written in the script, without comments and with normalized spaces.
+func (parser *ddlParser) text(start int, end int) string {
+	text := &strings.Builder{}
+	for i, token := range parser.tokens[start:end] {
+		if i > 0 {
+			previous := parser.tokens[start+i-1]
+			joined := previous.kind == ddlSymbol && (previous.text == "(" || token.kind == ddlSymbol) ||
+				token.kind == ddlSymbol && (token.text == ")" || token.text == ",")
+			if !joined {
+				text.WriteString(" ")
+			}
+		}
+		switch token.kind {
+		case ddlIdentifier:
+			text.WriteString(MysqlDialect{}.QuoteIdentifier(token.text))
+		case ddlString:
+			text.WriteString(quoteSqlString(token.text))
+		default:
+			text.WriteString(token.text)
+		}
+	}
+	return text.String()
+}
+
+func (parser *ddlParser) done() bool {
+	return parser.pos >= len(parser.tokens)
+}
+
+func (parser *ddlParser) peek(offset int) *ddlToken {
+	if parser.pos+offset >= len(parser.tokens) {
+		return nil
+	}
+	return &parser.tokens[parser.pos+offset]
+}
+
+func (parser *ddlParser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if len(parser.tokens) > 0 {
+		line = parser.tokens[len(parser.tokens)-1].line
+	}
+	if token := parser.peek(0); token != nil {
+		line = token.line
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// isWord reports whether the token at offset is the unquoted keyword word.
+func (parser *ddlParser) isWord(offset int, word string) bool {
+	token := parser.peek(offset)
+	return token != nil && token.kind == ddlWord && strings.EqualFold(token.text, word)
+}
+
+func (parser *ddlParser) isSymbol(symbol string) bool {
+	token := parser.peek(0)
+	return token != nil && token.kind == ddlSymbol && token.text == symbol
+}
+
+// acceptWords consumes the keywords words if the next tokens are exactly these keywords.
+func (parser *ddlParser) acceptWords(words ...string) bool {
+	for i, word := range words {
+		if !parser.isWord(i, word) {
+			return false
+		}
+	}
+	parser.pos += len(words)
+	return true
+}
+
+func (parser *ddlParser) acceptSymbol(symbol string) bool {
+	if parser.isSymbol(symbol) {
+		parser.pos++
+		return true
+	}
+	return false
+}
+
+func (parser *ddlParser) expectSymbol(symbol string) error {
+	if !parser.acceptSymbol(symbol) {
+		return parser.errorf("expected %s", symbol)
+	}
+	return nil
+}
+
+// name consumes an identifier, which may be qualified with a database name that is discarded.
+func (parser *ddlParser) name() (string, error) {
+	token := parser.peek(0)
+	if token == nil || token.kind != ddlWord && token.kind != ddlIdentifier {
+		return "", parser.errorf("expected identifier")
+	}
+	parser.pos++
+	if parser.isSymbol(".") {
+		parser.pos++
+		return parser.name()
+	}
+	return token.text, nil
+}
+
+// skipStatement skips the tokens up to and including the next semicolon.
+func (parser *ddlParser) skipStatement() {
+	for !parser.done() {
+		if parser.acceptSymbol(";") {
+			return
+		}
+		parser.pos++
+	}
+}
+
+// skipExpression skips a token, or a parenthesized list of tokens.
+func (parser *ddlParser) skipExpression() {
+	depth := 0
+	for !parser.done() {
+		if parser.isSymbol("(") {
+			depth++
+		} else if parser.isSymbol(")") {
+			depth--
+		}
+		parser.pos++
+		if depth <= 0 && !parser.isSymbol("(") {
+			return
+		}
+	}
+}
+
+// skipDefinition skips the remaining tokens of a column or key definition, or of a clause of ALTER TABLE.
+func (parser *ddlParser) skipDefinition() {
+	for !parser.done() && !parser.isSymbol(",") && !parser.isSymbol(")") && !parser.isSymbol(";") {
+		parser.skipExpression()
+	}
+}
+
+func (parser *ddlParser) parseCreateTable() error {
+	parser.acceptWords("IF", "NOT", "EXISTS")
+	name, err := parser.name()
+	if err != nil {
+		return err
+	}
+	if _, exists := parser.schema.Tables[name]; exists {
+		return parser.errorf("table %s is created twice", name)
+	}
+	if parser.isWord(0, "LIKE") || parser.isWord(0, "AS") || parser.isWord(0, "SELECT") {
+		return parser.errorf("table %s must be created with column definitions", name)
+	}
+	table := &MainTable{
+		Table:      NewTable(name),
+		AuxTables:  []*Table{},
+		Edges:      []*Edge{},
+		ValueLists: []*ValueList{},
+		yielded:    true,
+	}
+	if err := parser.expectSymbol("("); err != nil {
+		return err
+	}
+	for {
+		if err := parser.parseDefinition(table.Table); err != nil {
+			return err
+		}
+		if parser.acceptSymbol(")") {
+			break
+		}
+		if err := parser.expectSymbol(","); err != nil {
+			return err
+		}
+	}
+	parser.parseTableOptions(table.Table)
+	parser.schema.Tables[name] = table
+	return nil
+}
+
+// parseTableOptions reads the options following the definitions of a table, up to the end of the statement.
+// Options that are not in TableOptions are skipped with a warning, except for AUTO_INCREMENT=5, which is not part of the schema.
+func (parser *ddlParser) parseTableOptions(table *Table) {
+	for !parser.done() && !parser.acceptSymbol(";") {
+		var option *string
+		start := parser.pos
+		switch {
+		case parser.acceptWords("AUTO_INCREMENT"):
+			parser.acceptSymbol("=")
+			parser.skipExpression() // the next value of the counter
+			continue
+		case parser.isWord(0, "PARTITION"):
+			parser.skipDefinition()
+			parser.ignored(start, table, "")
+			continue
+		case parser.acceptWords("ENGINE"):
+			option = &table.Options.Engine
+		case parser.acceptWords("DEFAULT", "CHARSET"), parser.acceptWords("CHARSET"),
+			parser.acceptWords("DEFAULT", "CHARACTER", "SET"), parser.acceptWords("CHARACTER", "SET"):
+			option = &table.Options.Charset
+		case parser.acceptWords("DEFAULT", "COLLATE"), parser.acceptWords("COLLATE"):
+			option = &table.Options.Collate
+		case parser.acceptWords("ROW_FORMAT"):
+			option = &table.Options.RowFormat
+		case parser.acceptWords("COMMENT"):
+			option = &table.Options.Comment
+		default:
+			parser.skipExpression() // the name of the option, which may be two words such as DEFAULT ENCRYPTION
+			if next := parser.peek(1); next != nil && parser.peek(0).kind == ddlWord && next.kind == ddlSymbol && next.text == "=" {
+				parser.skipExpression()
+			}
+			if parser.acceptSymbol("=") {
+				parser.skipExpression()
+			}
+			parser.ignored(start, table, "")
+			continue
+		}
+		parser.acceptSymbol("=")
+		if token := parser.peek(0); token != nil && token.kind != ddlSymbol {
+			*option = token.text
+			parser.pos++
+		}
+	}
+	table.Options.RowFormat = strings.ToUpper(table.Options.RowFormat)
+}
+
+func (parser *ddlParser) parseCreateIndex(unique bool) error {
+	indexName, err := parser.name()
+	if err != nil {
+		return err
+	}
+	using := parser.pos
+	if parser.acceptWords("USING") {
+		parser.pos++
+	}
+	usingEnd := parser.pos
+	if !parser.acceptWords("ON") {
+		return parser.errorf("expected ON")
+	}
+	tableName, err := parser.name()
+	if err != nil {
+		return err
+	}
+	table, exists := parser.schema.Tables[tableName]
+	if !exists {
+		return parser.errorf("index %s is created on unknown table %s", indexName, tableName)
+	}
+	if usingEnd > using {
+		parser.warn(parser.tokens[using].line, table.Table, "", "%s is ignored, because the schema cannot represent it", parser.text(using, usingEnd))
+	}
+	columns, err := parser.columnList(table.Table)
+	if err != nil {
+		return err
+	}
+	if unique {
+		table.UniqueKeys[indexName] = columns
+	} else {
+		table.CompositeKeys[indexName] = columns
+	}
+	start := parser.pos
+	parser.skipDefinition()
+	parser.ignored(start, table.Table, "")
+	parser.skipStatement()
+	return nil
+}
+
+// parseAlterTable reads the keys added by ALTER TABLE to a table created earlier in the script.
+// Foreign keys added this way are marked as deferred, and other clauses are skipped with a warning.
+func (parser *ddlParser) parseAlterTable() error {
+	tableName, err := parser.name()
+	if err != nil {
+		return err
+	}
+	table, exists := parser.schema.Tables[tableName]
+	if !exists {
+		return parser.errorf("unknown table %s is altered", tableName)
+	}
+	for {
+		start := parser.pos
+		if parser.acceptWords("ADD") && (parser.isWord(0, "CONSTRAINT") || parser.isWord(0, "PRIMARY") || parser.isWord(0, "UNIQUE") ||
+			parser.isWord(0, "KEY") || parser.isWord(0, "INDEX") || parser.isWord(0, "FOREIGN")) {
+			foreignKeys := len(table.ForeignKeys)
+			if err := parser.parseDefinition(table.Table); err != nil {
+				return err
+			}
+			for i := foreignKeys; i < len(table.ForeignKeys); i++ {
+				table.ForeignKeys[i].Deferred = true
+			}
+		} else {
+			parser.pos = start
+			parser.skipDefinition()
+			parser.ignored(start, table.Table, "")
+		}
+		if !parser.acceptSymbol(",") {
+			break
+		}
+	}
+	parser.skipStatement()
+	return nil
+}
+
+// columnList consumes a parenthesized list of column names of table, skipping prefix lengths and sort orders with a warning.
+func (parser *ddlParser) columnList(table *Table) ([]string, error) {
+	if err := parser.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	columns := []string{}
+	for {
+		column, err := parser.name()
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+		start := parser.pos
+		for !parser.isSymbol(",") && !parser.isSymbol(")") && !parser.done() {
+			parser.skipExpression()
+		}
+		parser.ignored(start, table, column)
+		if parser.acceptSymbol(")") {
+			return columns, nil
+		}
+		if err := parser.expectSymbol(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// indexName consumes the optional name of an index of table, which defaults to the name of its first column as in MySQL.
+func (parser *ddlParser) indexName(table *Table) (string, []string, error) {
+	name := ""
+	if !parser.isSymbol("(") && !parser.isWord(0, "USING") {
+		var err error
+		if name, err = parser.name(); err != nil {
+			return "", nil, err
+		}
+	}
+	start := parser.pos
+	if parser.acceptWords("USING") {
+		parser.pos++
+	}
+	parser.ignored(start, table, "")
+	columns, err := parser.columnList(table)
+	if err != nil {
+		return "", nil, err
+	}
+	if name == "" {
+		name = columns[0]
+	}
+	return name, columns, nil
+}
+
+func (parser *ddlParser) parseDefinition(table *Table) error {
+	start := parser.pos
+	constraint := ""
+	if parser.acceptWords("CONSTRAINT") {
+		if !parser.isWord(0, "PRIMARY") && !parser.isWord(0, "UNIQUE") && !parser.isWord(0, "FOREIGN") && !parser.isWord(0, "CHECK") {
+			var err error
+			if constraint, err = parser.name(); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch {
+	case parser.acceptWords("PRIMARY", "KEY"):
+		using := parser.pos
+		if parser.acceptWords("USING") {
+			parser.pos++
+		}
+		parser.ignored(using, table, "")
+		columns, err := parser.columnList(table)
+		if err != nil {
+			return err
+		}
+		table.PrimaryKeys = columns
+
+	case parser.acceptWords("UNIQUE"):
+		if !parser.acceptWords("KEY") {
+			parser.acceptWords("INDEX")
+		}
+		name, columns, err := parser.indexName(table)
+		if err != nil {
+			return err
+		}
+		table.UniqueKeys[name] = columns
+
+	case parser.acceptWords("KEY"), parser.acceptWords("INDEX"):
+		name, columns, err := parser.indexName(table)
+		if err != nil {
+			return err
+		}
+		table.CompositeKeys[name] = columns
+
+	case parser.acceptWords("FOREIGN", "KEY"):
+		if !parser.isSymbol("(") {
+			if _, err := parser.name(); err != nil {
+				return err
+			}
+		}
+		sourceColumns, err := parser.columnList(table)
+		if err != nil {
+			return err
+		}
+		if !parser.acceptWords("REFERENCES") {
+			return parser.errorf("expected REFERENCES")
+		}
+		refTable, err := parser.name()
+		if err != nil {
+			return err
+		}
+		refColumns, err := parser.columnList(table)
+		if err != nil {
+			return err
+		}
+		if len(refColumns) != len(sourceColumns) {
+			return parser.errorf("foreign key on %s references %d columns with %d columns", table.Name, len(refColumns), len(sourceColumns))
+		}
+		foreign := MakeForeignKey(refTable)
+		foreign.Name = constraint
+		foreign.SourceColumns = sourceColumns
+		foreign.RefColumns = refColumns
+		for parser.acceptWords("ON") {
+			isDelete := parser.acceptWords("DELETE")
+			if !isDelete && !parser.acceptWords("UPDATE") {
+				return parser.errorf("expected DELETE or UPDATE")
+			}
+			option, err := parser.referenceOption()
+			if err != nil {
+				return err
+			}
+			if isDelete {
+				foreign.OnDelete = option
+			} else {
+				foreign.OnUpdate = option
+			}
+		}
+		table.ForeignKeys = append(table.ForeignKeys, foreign)
+
+	case parser.isWord(0, "CHECK"), parser.isWord(0, "FULLTEXT"), parser.isWord(0, "SPATIAL"):
+		parser.skipDefinition()
+		parser.ignored(start, table, "")
+		return nil
+
+	default:
+		return parser.parseColumn(table)
+	}
+
+	rest := parser.pos
+	parser.skipDefinition()
+	parser.ignored(rest, table, "")
+	return nil
+}
+
+func (parser *ddlParser) referenceOption() (ReferenceOption, error) {
+	switch {
+	case parser.acceptWords("RESTRICT"):
+		return ReferenceOptionRestrict, nil
+	case parser.acceptWords("CASCADE"):
+		return ReferenceOptionCascade, nil
+	case parser.acceptWords("SET", "NULL"):
+		return ReferenceOptionSetNull, nil
+	case parser.acceptWords("NO", "ACTION"):
+		return ReferenceOptionNoAction, nil
+	}
+	return "", parser.errorf("unknown reference option")
+}
+
+func (parser *ddlParser) parseColumn(table *Table) error {
+	name, err := parser.name()
+	if err != nil {
+		return err
+	}
+	typeToken := parser.peek(0)
+	if typeToken == nil || typeToken.kind != ddlWord {
+		return parser.errorf("expected type of column %s.%s", table.Name, name)
+	}
+	parser.pos++
+	typeName := strings.ToUpper(typeToken.text)
+	if typeName == "DOUBLE" {
+		parser.acceptWords("PRECISION")
+	}
+	args := []string{}
+	if parser.acceptSymbol("(") {
+		for !parser.acceptSymbol(")") {
+			if parser.done() {
+				return parser.errorf("unterminated type of column %s.%s", table.Name, name)
+			}
+			if !parser.isSymbol(",") {
+				args = append(args, parser.peek(0).text)
+			}
+			parser.pos++
+		}
+	}
+	unsigned := false
+	for {
+		if parser.acceptWords("UNSIGNED") {
+			unsigned = true
+		} else if !parser.acceptWords("SIGNED") && !parser.acceptWords("ZEROFILL") {
+			break
+		}
+	}
+
+	mysqlType, err := ddlColumnType(typeName, args, unsigned)
+	if err != nil {
+		return fmt.Errorf("line %d: column %s.%s: %v", typeToken.line, table.Name, name, err)
+	}
+	if typeName == "DATETIME" {
+		parser.warn(typeToken.line, table, name, "DATETIME is read as TIMESTAMP, which only holds times from 1970 to 2038 and stores them in UTC")
+	}
+	field := &MysqlField{Name: name, Type: mysqlType, Nullable: true}
+	if typeName == "ENUM" || typeName == "SET" {
+		field.Values = args
+	}
+
+	var defaultValue, onUpdate string
+	defaultQuoted := false
+	for !parser.done() && !parser.isSymbol(",") && !parser.isSymbol(")") {
+		start := parser.pos
+		switch {
+		case parser.acceptWords("DEFAULT"):
+			defaultValue, defaultQuoted = parser.defaultValue()
+			if defaultValue == "" && !defaultQuoted {
+				parser.ignored(start, table, name)
+			}
+		case parser.acceptWords("ON", "UPDATE"):
+			if parser.currentTimestamp() {
+				onUpdate = "CURRENT_TIMESTAMP"
+			} else {
+				parser.skipExpression()
+				parser.ignored(start, table, name)
+			}
+		case parser.acceptWords("NOT", "NULL"):
+			field.Nullable = false
+		case parser.acceptWords("NULL"):
+			field.Nullable = true
+		case parser.acceptWords("AUTO_INCREMENT"):
+			field.AutoIncrement = true
+		case parser.acceptWords("GENERATED", "ALWAYS", "AS"), parser.acceptWords("AS"):
+			if field.JsonPath, err = parser.jsonPath(); err != nil {
+				return err
+			}
+			parser.acceptWords("VIRTUAL") // stored generated columns are reported as other clauses
+		case parser.acceptWords("PRIMARY", "KEY"), parser.acceptWords("KEY"):
+			table.PrimaryKeys = []string{name}
+			field.Nullable = false
+		case parser.acceptWords("UNIQUE"):
+			parser.acceptWords("KEY")
+			table.UniqueKeys[name] = []string{name}
+		case parser.acceptWords("COMMENT"):
+			if token := parser.peek(0); token != nil && token.kind == ddlString {
+				field.Comment = token.text
+				parser.pos++
+			}
+		case parser.acceptWords("COLLATE"),
+			parser.acceptWords("CHARSET"), parser.acceptWords("CHARACTER", "SET"):
+			parser.skipExpression() // the value, which may be a keyword
+			parser.ignored(start, table, name)
+		default:
+			parser.skipExpression()
+			parser.ignored(start, table, name)
+		}
+	}
+	for _, key := range table.PrimaryKeys {
+		if key == name {
+			field.Nullable = false
+		}
+	}
+	if defaultValue != "" || defaultQuoted {
+		if field.Default, err = columnDefault(field, defaultValue, defaultQuoted); err != nil {
+			return fmt.Errorf("line %d: column %s.%s: %v", typeToken.line, table.Name, name, err)
+		}
+	}
+	if onUpdate != "" {
+		if field.OnUpdate, err = columnOnUpdate(field, onUpdate); err != nil {
+			return fmt.Errorf("line %d: column %s.%s: %v", typeToken.line, table.Name, name, err)
+		}
+	}
+
+	table.SimpleFields = append(table.SimpleFields, field)
+	return nil
+}
+
+// defaultValue consumes the value of a DEFAULT clause and returns it with whether it is a string literal.
+// Expressions other than the ones accepted by columnDefault are skipped, and an empty unquoted value is returned.
+func (parser *ddlParser) defaultValue() (string, bool) {
+	start := parser.pos
+	if parser.acceptSymbol("(") && parser.acceptWords("UUID") && parser.acceptSymbol("(") && parser.acceptSymbol(")") && parser.acceptSymbol(")") {
+		return "UUID()", false
+	}
+	parser.pos = start
+	if parser.currentTimestamp() {
+		return "CURRENT_TIMESTAMP", false
+	}
+	token := parser.peek(0)
+	switch {
+	case token == nil:
+		return "", false
+	case token.kind == ddlString:
+		parser.pos++
+		return token.text, true
+	case token.kind == ddlNumber:
+		parser.pos++
+		return token.text, false
+	case token.kind == ddlSymbol && token.text == "-":
+		if number := parser.peek(1); number != nil && number.kind == ddlNumber {
+			parser.pos += 2
+			return "-" + number.text, false
+		}
+	case parser.isWord(0, "NULL") || parser.isWord(0, "TRUE") || parser.isWord(0, "FALSE"):
+		parser.pos++
+		return strings.ToUpper(token.text), false
+	}
+	parser.skipExpression()
+	return "", false
+}
+
+// currentTimestamp consumes CURRENT_TIMESTAMP or one of its synonyms, with an optional precision.
+func (parser *ddlParser) currentTimestamp() bool {
+	for _, word := range [...]string{"CURRENT_TIMESTAMP", "NOW", "LOCALTIME", "LOCALTIMESTAMP"} {
+		if parser.acceptWords(word) {
+			if parser.isSymbol("(") {
+				parser.skipExpression()
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// jsonPath parses the parenthesized expression of a generated column, which must extract a path from a JSON column
+// with ->, ->>, JSON_EXTRACT or JSON_UNQUOTE(JSON_EXTRACT(...)), as written by mysqlGeneratedClause or SHOW CREATE TABLE.
+func (parser *ddlParser) jsonPath() (*JsonPath, error) {
+	if err := parser.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	closing := 1
+	if parser.acceptWords("JSON_UNQUOTE") {
+		if err := parser.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		closing++
+	}
+	extract := parser.acceptWords("JSON_EXTRACT")
+	if extract {
+		if err := parser.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		closing++
+	}
+	column, err := parser.name()
+	if err != nil {
+		return nil, err
+	}
+	if extract {
+		if err := parser.expectSymbol(","); err != nil {
+			return nil, err
+		}
+	} else if !parser.acceptSymbol("-") || !parser.acceptSymbol(">") {
+		return nil, parser.errorf("only generated columns extracting a path from a JSON column are supported")
+	} else {
+		parser.acceptSymbol(">")
+	}
+	if token := parser.peek(0); token != nil && token.kind == ddlWord && strings.HasPrefix(token.text, "_") {
+		parser.pos++ // character set introducer, such as _utf8mb4'$.a'
+	}
+	token := parser.peek(0)
+	if token == nil || token.kind != ddlString {
+		return nil, parser.errorf("expected JSON path")
+	}
+	if !jsonPathPattern.MatchString(token.text) {
+		return nil, parser.errorf("unsupported JSON path %s", token.text)
+	}
+	parser.pos++
+	for ; closing > 0; closing-- {
+		if err := parser.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+	}
+	return &JsonPath{Column: column, Path: token.text}, nil
+}
+
+// parseMysqlType returns the type of a column declared in a tag, such as "VARCHAR(64)" or "INT UNSIGNED",
+// in the form written by SimpleToMysqlType. ENUM and SET types are declared with the enum tag instead.
+func parseMysqlType(declaration string) (string, error) {
+	match := mysqlTypePattern.FindStringSubmatch(strings.TrimSpace(declaration))
+	if match == nil {
+		return "", fmt.Errorf("invalid type %q", declaration)
+	}
+	args := []string{}
+	if match[2] != "" {
+		for _, arg := range strings.Split(match[2], ",") {
+			args = append(args, strings.TrimSpace(arg))
+		}
+	}
+	unsigned := false
+	for _, word := range strings.Fields(strings.ToUpper(match[3])) {
+		if word != "UNSIGNED" && word != "SIGNED" {
+			return "", fmt.Errorf("invalid type %q", declaration)
+		}
+		unsigned = word == "UNSIGNED"
+	}
+	return ddlColumnType(strings.ToUpper(match[1]), args, unsigned)
+}
+
+// ddlColumnType returns the type of a column in the form written by SimpleToMysqlType.
+func ddlColumnType(typeName string, args []string, unsigned bool) (string, error) {
+	sign := elvis.Ternary(unsigned, " UNSIGNED", " SIGNED").(string)
+	switch typeName {
+	case "BOOL", "BOOLEAN":
+		return "BOOL", nil
+	case "TINYINT":
+		if len(args) == 1 && args[0] == "1" && !unsigned {
+			return "BOOL", nil // the conventional declaration of booleans
+		}
+		return "TINYINT" + sign, nil
+	case "SMALLINT", "BIGINT":
+		return typeName + sign, nil
+	case "MEDIUMINT", "INT", "INTEGER":
+		return "INT" + sign, nil
+	case "FLOAT":
+		return "FLOAT", nil
+	case "DOUBLE", "REAL":
+		return "DOUBLE", nil
+	case "VARCHAR", "CHAR":
+		width := "1"
+		if len(args) > 0 {
+			width = args[0]
+		} else if typeName == "VARCHAR" {
+			return "", fmt.Errorf("VARCHAR requires a width")
+		}
+		return typeName + "(" + width + ")", nil
+	case "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB":
+		return typeName, nil
+	case "BINARY", "VARBINARY":
+		width := "1"
+		if len(args) > 0 {
+			width = args[0]
+		} else if typeName == "VARBINARY" {
+			return "", fmt.Errorf("VARBINARY requires a width")
+		}
+		return typeName + "(" + width + ")", nil
+	case "TIMESTAMP", "DATETIME":
+		return "TIMESTAMP", nil
+	case "JSON":
+		return "JSON", nil
+	case "ENUM", "SET":
+		return enumMysqlType(typeName, args)
+	case "DECIMAL", "NUMERIC", "DEC", "FIXED":
+		precision, scale := "10", "0"
+		if len(args) > 0 {
+			precision = args[0]
+		}
+		if len(args) > 1 {
+			scale = args[1]
+		}
+		return "DECIMAL(" + precision + "," + scale + ")", nil
+	}
+	return "", fmt.Errorf("unsupported type %s", typeName)
+}
@@ -0,0 +1,137 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"strings"
+	"testing"
+)
+
+// outputModels returns the model structs written for the tables in ddl.
+func outputModels(t *testing.T, ddl string) (string, *Schema) {
+	t.Helper()
+	schema, err := ParseDdl(strings.NewReader(ddl))
+	if err != nil {
+		t.Fatalf("parse DDL: %v", err)
+	}
+	models := &strings.Builder{}
+	if err := schema.OutputModels(GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", GoStream: models}); err != nil {
+		t.Fatalf("output models: %v", err)
+	}
+	return models.String(), schema
+}
+
+func TestParseDdlRoundTrip(t *testing.T) {
+	_, _, models := sourceModels(t, repositoryModels, "User")
+	ddl := outputSql(t, models, MysqlDialect{})
+
+	source, schema := outputModels(t, ddl)
+	if diagnostics := schema.Diagnostics(); len(diagnostics) > 0 {
+		t.Errorf("expected the generated DDL to be read without warnings, got %v", diagnostics)
+	}
+	_, _, written := sourceModels(t, source, "User")
+	if again := outputSql(t, written, MysqlDialect{}); again != ddl {
+		t.Errorf("the models written for\n%s\nwere\n%s\nwhich generate\n%s", ddl, source, again)
+	}
+}
+
+func TestOutputModelsTodos(t *testing.T) {
+	source, schema := outputModels(t, strings.Join([]string{
+		"CREATE TABLE `Team` (",
+		"\t`Id` INT UNSIGNED NOT NULL AUTO_INCREMENT,",
+		"\tPRIMARY KEY (`Id`)",
+		");",
+		"CREATE TABLE `member` (",
+		"\t`Id` INT UNSIGNED NOT NULL AUTO_INCREMENT,",
+		"\t`joined_at` DATETIME NOT NULL,",
+		"\t`Owner` INT UNSIGNED NOT NULL,",
+		"\tPRIMARY KEY (`Id`),",
+		"\tCONSTRAINT `fk_owner` FOREIGN KEY (`Owner`) REFERENCES `Team`(`Id`)",
+		");",
+	}, "\n"))
+	for _, expected := range []string{
+		"_        struct{}  `table:\"member\"`",
+		"JoinedAt time.Time `column:\"joined_at\"` // TODO: DATETIME is read as TIMESTAMP",
+		"Owner    uint32    // TODO: the foreign key of Owner referencing Team is not declared",
+	} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("expected the models to contain %q, got\n%s", expected, source)
+		}
+	}
+	if diagnostics := schema.Diagnostics(); len(diagnostics) != 2 || diagnostics[0].Severity != SeverityWarning || diagnostics[0].Field != "joined_at" || diagnostics[1].Field != "Owner" {
+		t.Errorf("expected warnings about the DATETIME column and the undeclared foreign key, got %v", diagnostics)
+	}
+
+	_, _, models := sourceModels(t, source, "Member")
+	if _, err := BuildSchemaFromTypes(models); err != nil {
+		t.Errorf("build the schema of the written models: %v", err)
+	}
+}
+
+// TestParseDdlWarnings checks that the clauses of a mysqldump script the schema and the models cannot represent are reported.
+func TestParseDdlWarnings(t *testing.T) {
+	ddl := strings.Join([]string{
+		"CREATE TABLE `User` (",
+		"  `Id` int unsigned NOT NULL AUTO_INCREMENT,",
+		"  `Name` varchar(64) COLLATE utf8mb4_bin NOT NULL,",
+		"  PRIMARY KEY (`Id`),",
+		"  CONSTRAINT `name_set` CHECK (`Name` <> '')",
+		") ENGINE=InnoDB AUTO_INCREMENT=5 DEFAULT CHARSET=utf8mb4;",
+		"CREATE TABLE `Post` (",
+		"  `Id` int unsigned NOT NULL AUTO_INCREMENT,",
+		"  `User_Id` int unsigned NOT NULL,",
+		"  `Title` varchar(64) NOT NULL,",
+		"  `Tag` varchar(16) NOT NULL,",
+		"  PRIMARY KEY (`Id`),",
+		"  KEY `by_tag` (`Tag`),",
+		"  KEY `by_title` (`Title`,`Tag`),",
+		"  CONSTRAINT `post_ibfk_1` FOREIGN KEY (`User_Id`) REFERENCES `User` (`Id`)",
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+	}, "\n")
+	schema, err := ParseDdl(strings.NewReader(ddl))
+	if err != nil {
+		t.Fatalf("parse DDL: %v", err)
+	}
+	sql := &strings.Builder{}
+	if err := schema.OutputSql(GeneratorConfig{Indent: "\t", Eol: "\n", SqlStream: sql}); err != nil {
+		t.Fatalf("output SQL: %v", err)
+	}
+	if !strings.Contains(sql.String(), "CONSTRAINT `post_ibfk_1` FOREIGN KEY (`User_Id`)") {
+		t.Errorf("expected the constraint name to be kept, got\n%s", sql)
+	}
+
+	if err := schema.OutputModels(GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", GoStream: &strings.Builder{}}); err != nil {
+		t.Fatalf("output models: %v", err)
+	}
+	expected := []struct{ field, message string }{
+		{"Name", "COLLATE utf8mb4_bin is ignored"},
+		{"", "CONSTRAINT `name_set` CHECK (`Name` <> '') is ignored"},
+		{"User_Id", "the foreign key post_ibfk_1 is named fk_Post_User_Id by the models"},
+		{"Tag", "the index by_title does not contain this column"},
+	}
+	diagnostics := schema.Diagnostics()
+	if len(diagnostics) != len(expected) {
+		t.Errorf("expected %d warnings, got %v", len(expected), diagnostics)
+	}
+	for i := 0; i < len(expected) && i < len(diagnostics); i++ {
+		if diagnostics[i].Field != expected[i].field || !strings.HasPrefix(diagnostics[i].Message, expected[i].message) {
+			t.Errorf("expected a warning on %q starting with %q, got %v", expected[i].field, expected[i].message, diagnostics[i])
+		}
+	}
+}
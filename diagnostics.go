@@ -0,0 +1,115 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityNote // not a problem, but a decision about the schema that the models do not state explicitly
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityNote:
+		return "note"
+	}
+	return fmt.Sprintf("Severity(%d)", int(severity))
+}
+
+// Diagnostic is a problem found in the models.
+type Diagnostic struct {
+	Severity Severity
+	Position string // position of the field in the source code, empty if the models were loaded through reflection
+	Type     string // the model type containing the problem
+	Field    string // the field containing the problem, empty if the problem concerns the whole type
+	Tag      string // the struct tag key causing the problem, if any
+	Message  string
+}
+
+func (diagnostic *Diagnostic) Error() string {
+	location := diagnostic.Type
+	if diagnostic.Field != "" {
+		location += "." + diagnostic.Field
+	}
+	if diagnostic.Tag != "" {
+		location += " (tag " + diagnostic.Tag + ")"
+	}
+	if diagnostic.Position != "" {
+		location = diagnostic.Position + ": " + location
+	}
+	return location + ": " + diagnostic.Severity.String() + ": " + diagnostic.Message
+}
+
+// Diagnostics are all problems found in one run, so that every mistake in the models can be fixed at once.
+type Diagnostics []*Diagnostic
+
+func (diagnostics Diagnostics) Error() string {
+	lines := make([]string, 0, len(diagnostics))
+	for _, diagnostic := range diagnostics {
+		lines = append(lines, diagnostic.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (diagnostics Diagnostics) HasErrors() bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// reportField records a problem in a field of the model of table, where tag is the struct tag key causing the problem, if any.
+func (schema *Schema) reportField(severity Severity, table *MainTable, field string, tag string, format string, args ...interface{}) {
+	schema.diagnostics = append(schema.diagnostics, newFieldDiagnostic(severity, table, field, tag, format, args...))
+}
+
+// newFieldDiagnostic returns a problem in a field of the model of table, for problems that are returned instead of recorded.
+func newFieldDiagnostic(severity Severity, table *MainTable, field string, tag string, format string, args ...interface{}) *Diagnostic {
+	return &Diagnostic{
+		Severity: severity,
+		Position: table.fieldPositions[field],
+		Type:     table.Type.Name(),
+		Field:    field,
+		Tag:      tag,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// report records a problem in table that is not caused by a specific field of its model.
+func (schema *Schema) report(severity Severity, table *MainTable, format string, args ...interface{}) {
+	schema.reportField(severity, table, "", "", format, args...)
+}
+
+// Diagnostics returns the problems found while building the schema.
+func (schema *Schema) Diagnostics() Diagnostics {
+	return schema.diagnostics
+}
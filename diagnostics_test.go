@@ -0,0 +1,47 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import "testing"
+
+const invalidModels = `package models
+
+type Broken struct {
+	Id    uint32  ` + "`primaryKey:\"\"`" + `
+	Name  string
+	Ratio float32 ` + "`decimal:\"x\"`" + `
+}
+`
+
+func TestDiagnostics(t *testing.T) {
+	_, _, models := sourceModels(t, invalidModels, "Broken")
+	_, err := BuildSchemaFromTypes(models)
+	diagnostics, ok := err.(Diagnostics)
+	if !ok {
+		t.Fatalf("expected Diagnostics, got %v", err)
+	}
+	if !diagnostics.HasErrors() {
+		t.Errorf("expected the diagnostics to have errors: %v", diagnostics)
+	}
+	expected := "models.go:5:2: Broken.Name: error: string declaration must either have the width tag\n" +
+		"models.go:6:2: Broken.Ratio: error: decimal fields must be strings or implement driver.Valuer and sql.Scanner"
+	if diagnostics.Error() != expected {
+		t.Errorf("expected every problem to be reported with its position\n%s\ngot\n%s", expected, diagnostics.Error())
+	}
+}
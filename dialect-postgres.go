@@ -0,0 +1,153 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"github.com/hoop33/go-elvis"
+	"strings"
+)
+
+// PostgresDialect writes the schema for PostgreSQL 10 or later.
+//
+// PostgreSQL has no unsigned integers, so unsigned columns are widened to the next signed type.
+// ENUM and SET columns are stored as TEXT, with a CHECK constraint on the values of ENUM columns.
+// JSON columns are stored as JSONB.
+// UUID() defaults use gen_random_uuid, which requires PostgreSQL 13, and ON UPDATE expressions are ignored.
+// Comments are set with COMMENT ON statements, and the other table options are ignored.
+// Index names are global in PostgreSQL, so they are prefixed with the table name.
+type PostgresDialect struct{}
+
+func (PostgresDialect) ColumnDefinition(field *MysqlField) []string {
+	return []string{
+		postgresColumnType(field.Type, field.AutoIncrement),
+		elvis.Ternary(field.Nullable, "", "NOT NULL").(string),
+		postgresDefaultClause(field),
+		elvis.Ternary(field.AutoIncrement, "GENERATED BY DEFAULT AS IDENTITY", "").(string),
+		enumCheck(PostgresDialect{}, field),
+		postgresGeneratedClause(field),
+	}
+}
+
+func (dialect PostgresDialect) TableOptions(table *Table, options TableOptions) (string, []string) {
+	statements := []string{}
+	if options.Comment != "" {
+		statements = append(statements, "COMMENT ON TABLE "+dialect.QuoteIdentifier(table.Name)+" IS "+quoteSqlString(options.Comment)+";")
+	}
+	for _, field := range table.SimpleFields {
+		if field.Comment != "" {
+			statements = append(statements, "COMMENT ON COLUMN "+dialect.QuoteIdentifier(table.Name)+"."+dialect.QuoteIdentifier(field.Name)+" IS "+quoteSqlString(field.Comment)+";")
+		}
+	}
+	return "", statements
+}
+
+func (dialect PostgresDialect) PrimaryKey(table *Table) string {
+	return primaryKeyClause(dialect, table.PrimaryKeys)
+}
+
+func (dialect PostgresDialect) UniqueKey(table *Table, indexName string, columns []string) string {
+	return "CONSTRAINT " + dialect.QuoteIdentifier(table.Name+"_"+indexName) + " UNIQUE (" + quoteIdentifiers(dialect, columns) + ")"
+}
+
+func (dialect PostgresDialect) CompositeKey(table *Table, indexName string, columns []string) (string, bool) {
+	return "CREATE INDEX " + dialect.QuoteIdentifier(table.Name+"_"+indexName) + " ON " + dialect.QuoteIdentifier(table.Name) + " (" + quoteIdentifiers(dialect, columns) + ");", false
+}
+
+// AddForeignKey returns a deferrable foreign key, which is checked when the transaction inserting the rows of the cycle commits.
+func (dialect PostgresDialect) AddForeignKey(table *Table, foreign ForeignKey) string {
+	statement := addForeignKeyStatement(dialect, table, foreign)
+	if foreign.Deferred {
+		statement = strings.TrimSuffix(statement, ";") + " DEFERRABLE INITIALLY DEFERRED;"
+	}
+	return statement
+}
+
+func (PostgresDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func (PostgresDialect) Rebind(query string) string {
+	return rebindNumbered(query, "$")
+}
+
+func (PostgresDialect) InsertReturning() bool {
+	return true
+}
+
+// postgresGeneratedClause returns the clause computing a generated column from a JSON column.
+// PostgreSQL 12 only supports stored generated columns.
+func postgresGeneratedClause(field *MysqlField) string {
+	if field.JsonPath == nil {
+		return ""
+	}
+	path := "{" + strings.Join(jsonPathElements(field.JsonPath.Path), ",") + "}"
+	return "GENERATED ALWAYS AS ((" + PostgresDialect{}.QuoteIdentifier(field.JsonPath.Column) + " #>> " + quoteSqlString(path) + ")::" + postgresColumnType(field.Type, false) + ") STORED"
+}
+
+// postgresDefaultClause returns the DEFAULT clause of field, if any.
+func postgresDefaultClause(field *MysqlField) string {
+	switch field.Default {
+	case "":
+		return ""
+	case "UUID()":
+		return "DEFAULT gen_random_uuid()::text"
+	}
+	return "DEFAULT " + field.Default
+}
+
+// postgresColumnType translates the MySQL type of a column to the PostgreSQL type storing the same range of values.
+func postgresColumnType(mysqlType string, autoIncrement bool) string {
+	switch mysqlType {
+	case "BOOL":
+		return "BOOLEAN"
+	case "TINYINT SIGNED", "SMALLINT SIGNED", "TINYINT UNSIGNED":
+		return "SMALLINT"
+	case "INT SIGNED", "SMALLINT UNSIGNED":
+		return "INTEGER"
+	case "BIGINT SIGNED", "INT UNSIGNED":
+		return "BIGINT"
+	case "BIGINT UNSIGNED":
+		if autoIncrement {
+			return "BIGINT" // identity columns must be integers; the upper half of the range is unlikely to be used
+		}
+		return "NUMERIC(20)"
+	case "FLOAT":
+		return "REAL"
+	case "DOUBLE":
+		return "DOUBLE PRECISION"
+	case "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT":
+		return "TEXT"
+	case "TIMESTAMP":
+		return "TIMESTAMPTZ"
+	case "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB":
+		return "BYTEA"
+	case "JSON":
+		return "JSONB"
+	}
+	if strings.HasPrefix(mysqlType, "DECIMAL(") {
+		return "NUMERIC" + strings.TrimPrefix(mysqlType, "DECIMAL")
+	}
+	if strings.HasPrefix(mysqlType, "BINARY(") || strings.HasPrefix(mysqlType, "VARBINARY(") {
+		return "BYTEA" // PostgreSQL has no binary type with a length limit
+	}
+	if strings.HasPrefix(mysqlType, "ENUM(") || isSetType(mysqlType) {
+		return "TEXT" // values are checked by enumCheck
+	}
+	return mysqlType // VARCHAR(n) and CHAR(n) are the same in PostgreSQL
+}
@@ -0,0 +1,125 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"github.com/hoop33/go-elvis"
+	"strings"
+)
+
+// SqliteDialect writes the schema for SQLite 3, mainly to test code using the models without a database server.
+//
+// Columns are declared with the type affinities of SQLite, so the range of values is not checked,
+// except for ENUM columns, which have a CHECK constraint.
+// DECIMAL and JSON columns are stored as TEXT, which keeps decimal values exact.
+// UUID() defaults are random hexadecimal strings in the format of a UUID, and ON UPDATE expressions are ignored.
+// SQLite has neither table options nor comments, so they are ignored.
+// An auto increment column must be the only primary key of its table, because SQLite declares it as INTEGER PRIMARY KEY.
+// SQLite only enforces foreign keys if they are enabled with PRAGMA foreign_keys = ON.
+type SqliteDialect struct{}
+
+func (SqliteDialect) ColumnDefinition(field *MysqlField) []string {
+	return []string{
+		sqliteColumnType(field.Type, field.AutoIncrement),
+		elvis.Ternary(field.Nullable, "", "NOT NULL").(string),
+		sqliteDefaultClause(field),
+		elvis.Ternary(field.AutoIncrement, "PRIMARY KEY AUTOINCREMENT", "").(string),
+		enumCheck(SqliteDialect{}, field),
+		sqliteGeneratedClause(field),
+	}
+}
+
+func (SqliteDialect) TableOptions(table *Table, options TableOptions) (string, []string) {
+	return "", nil
+}
+
+func (dialect SqliteDialect) PrimaryKey(table *Table) string {
+	for _, key := range table.PrimaryKeys {
+		if field := table.FindField(key); field != nil && field.AutoIncrement {
+			return "" // declared in the column definition
+		}
+	}
+	return primaryKeyClause(dialect, table.PrimaryKeys)
+}
+
+func (dialect SqliteDialect) UniqueKey(table *Table, indexName string, columns []string) string {
+	return "CONSTRAINT " + dialect.QuoteIdentifier(table.Name+"_"+indexName) + " UNIQUE (" + quoteIdentifiers(dialect, columns) + ")"
+}
+
+func (dialect SqliteDialect) CompositeKey(table *Table, indexName string, columns []string) (string, bool) {
+	return "CREATE INDEX " + dialect.QuoteIdentifier(table.Name+"_"+indexName) + " ON " + dialect.QuoteIdentifier(table.Name) + " (" + quoteIdentifiers(dialect, columns) + ");", false
+}
+
+// AddForeignKey returns an empty string, since SQLite cannot add constraints to existing tables,
+// but only checks foreign keys when rows are written, so they can reference tables created later.
+func (SqliteDialect) AddForeignKey(table *Table, foreign ForeignKey) string {
+	return ""
+}
+
+func (SqliteDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func (SqliteDialect) Rebind(query string) string {
+	return query
+}
+
+func (SqliteDialect) InsertReturning() bool {
+	return false
+}
+
+// sqliteGeneratedClause returns the clause computing a generated column from a JSON column, which requires SQLite 3.31.
+func sqliteGeneratedClause(field *MysqlField) string {
+	if field.JsonPath == nil {
+		return ""
+	}
+	return "GENERATED ALWAYS AS (json_extract(" + SqliteDialect{}.QuoteIdentifier(field.JsonPath.Column) + ", " + quoteSqlString(field.JsonPath.Path) + ")) VIRTUAL"
+}
+
+// sqliteDefaultClause returns the DEFAULT clause of field, if any.
+func sqliteDefaultClause(field *MysqlField) string {
+	switch field.Default {
+	case "":
+		return ""
+	case "UUID()":
+		return "DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-' || hex(randomblob(2)) || '-' || hex(randomblob(2)) || '-' || hex(randomblob(6))))"
+	}
+	return "DEFAULT " + field.Default
+}
+
+// sqliteColumnType translates the MySQL type of a column to a type with the corresponding SQLite affinity.
+func sqliteColumnType(mysqlType string, autoIncrement bool) string {
+	if autoIncrement {
+		return "INTEGER" // AUTOINCREMENT is only allowed on INTEGER PRIMARY KEY
+	}
+	switch {
+	case mysqlType == "BOOL", strings.HasSuffix(mysqlType, "INT SIGNED"), strings.HasSuffix(mysqlType, "INT UNSIGNED"):
+		return "INTEGER"
+	case mysqlType == "FLOAT", mysqlType == "DOUBLE":
+		return "REAL"
+	case strings.HasSuffix(mysqlType, "TEXT"), strings.HasPrefix(mysqlType, "VARCHAR"), strings.HasPrefix(mysqlType, "CHAR"),
+		strings.HasPrefix(mysqlType, "ENUM("), isSetType(mysqlType),
+		strings.HasPrefix(mysqlType, "DECIMAL("), // NUMERIC affinity would convert decimals to floats
+		mysqlType == "JSON":
+		return "TEXT"
+	case strings.HasSuffix(mysqlType, "BLOB"), strings.HasPrefix(mysqlType, "BINARY("), strings.HasPrefix(mysqlType, "VARBINARY("):
+		return "BLOB"
+	}
+	return mysqlType // TIMESTAMP has NUMERIC affinity, and is recognized by drivers to scan the column into time.Time
+}
@@ -0,0 +1,190 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"github.com/hoop33/go-elvis"
+	"strconv"
+	"strings"
+)
+
+// Dialect renders a schema in the SQL of a specific database.
+//
+// Columns in a schema are always described with MySQL types, which a dialect translates to its own types,
+// so that snapshots and migrations do not depend on the dialect.
+type Dialect interface {
+	// ColumnDefinition returns the parts of the definition of a column following its name, starting with the type.
+	// A dialect returns the same number of parts for every column, using empty strings for absent parts,
+	// so that the parts can be aligned in CREATE TABLE statements.
+	ColumnDefinition(field *MysqlField) []string
+
+	// TableOptions returns the options following the definitions in CREATE TABLE, which may be empty,
+	// and the statements following CREATE TABLE, such as comments in databases without inline comments.
+	TableOptions(table *Table, options TableOptions) (string, []string)
+
+	// PrimaryKey returns the clause declaring the primary keys of a table in CREATE TABLE,
+	// or an empty string if they are already declared in the column definitions.
+	PrimaryKey(table *Table) string
+
+	// UniqueKey returns the clause declaring a unique key in CREATE TABLE.
+	UniqueKey(table *Table, indexName string, columns []string) string
+
+	// CompositeKey returns the declaration of a non-unique index,
+	// which is a clause in CREATE TABLE if inline is true, or a statement following CREATE TABLE otherwise.
+	CompositeKey(table *Table, indexName string, columns []string) (declaration string, inline bool)
+
+	// AddForeignKey returns the statement adding a foreign key closing a reference cycle after all tables are created,
+	// or an empty string if the database accepts foreign keys to tables that do not exist yet in CREATE TABLE.
+	AddForeignKey(table *Table, foreign ForeignKey) string
+
+	// QuoteIdentifier quotes the name of a table, column or index.
+	QuoteIdentifier(name string) string
+
+	// Rebind converts the "?" placeholders of a generated query to the placeholders of the dialect.
+	Rebind(query string) string
+
+	// InsertReturning reports whether the generated value of an auto increment column is obtained
+	// with INSERT ... RETURNING rather than sql.Result.LastInsertId.
+	InsertReturning() bool
+}
+
+func (config *GeneratorConfig) dialect() Dialect {
+	if config.Dialect == nil {
+		return MysqlDialect{}
+	}
+	return config.Dialect
+}
+
+// MysqlDialect is the default dialect, which writes the MySQL types of the schema as is.
+type MysqlDialect struct{}
+
+func (MysqlDialect) ColumnDefinition(field *MysqlField) []string {
+	return []string{
+		field.Type,
+		elvis.Ternary(field.Nullable, "", "NOT NULL").(string),
+		mysqlDefaultClause(field),
+		elvis.Ternary(field.OnUpdate != "", "ON UPDATE "+field.OnUpdate, "").(string),
+		elvis.Ternary(field.AutoIncrement, "AUTO_INCREMENT", "").(string),
+		mysqlGeneratedClause(field),
+		elvis.Ternary(field.Comment != "", "COMMENT "+quoteSqlString(field.Comment), "").(string),
+	}
+}
+
+func (MysqlDialect) TableOptions(table *Table, options TableOptions) (string, []string) {
+	return mysqlTableOptions(options), nil
+}
+
+// mysqlTableOptions returns the non-empty options in the form written by SHOW CREATE TABLE.
+func mysqlTableOptions(options TableOptions) string {
+	clauses := []string{}
+	if options.Engine != "" {
+		clauses = append(clauses, "ENGINE="+options.Engine)
+	}
+	if options.Charset != "" {
+		clauses = append(clauses, "DEFAULT CHARSET="+options.Charset)
+	}
+	if options.Collate != "" {
+		clauses = append(clauses, "COLLATE="+options.Collate)
+	}
+	if options.RowFormat != "" {
+		clauses = append(clauses, "ROW_FORMAT="+options.RowFormat)
+	}
+	if options.Comment != "" {
+		clauses = append(clauses, "COMMENT="+quoteSqlString(options.Comment))
+	}
+	return strings.Join(clauses, " ")
+}
+
+func (dialect MysqlDialect) PrimaryKey(table *Table) string {
+	return primaryKeyClause(dialect, table.PrimaryKeys)
+}
+
+func (dialect MysqlDialect) UniqueKey(table *Table, indexName string, columns []string) string {
+	return "UNIQUE KEY " + dialect.QuoteIdentifier(indexName) + " (" + quoteIdentifiers(dialect, columns) + ")"
+}
+
+func (dialect MysqlDialect) CompositeKey(table *Table, indexName string, columns []string) (string, bool) {
+	return "KEY " + dialect.QuoteIdentifier(indexName) + " (" + quoteIdentifiers(dialect, columns) + ")", true
+}
+
+func (dialect MysqlDialect) AddForeignKey(table *Table, foreign ForeignKey) string {
+	return addForeignKeyStatement(dialect, table, foreign)
+}
+
+func (MysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+func (MysqlDialect) Rebind(query string) string {
+	return query
+}
+
+func (MysqlDialect) InsertReturning() bool {
+	return false
+}
+
+// mysqlDefaultClause returns the DEFAULT clause of field, if any.
+// Expressions other than CURRENT_TIMESTAMP must be parenthesized, which requires MySQL 8.0.13.
+func mysqlDefaultClause(field *MysqlField) string {
+	switch field.Default {
+	case "":
+		return ""
+	case "UUID()":
+		return "DEFAULT (UUID())"
+	}
+	return "DEFAULT " + field.Default
+}
+
+// enumCheck returns the CHECK constraint emulating the ENUM type of field in other databases,
+// or an empty string if the field is not an enum.
+func enumCheck(dialect Dialect, field *MysqlField) string {
+	if len(field.Values) == 0 || isSetType(field.Type) {
+		return ""
+	}
+	quoted := make([]string, 0, len(field.Values))
+	for _, value := range field.Values {
+		quoted = append(quoted, quoteSqlString(value))
+	}
+	return "CHECK (" + dialect.QuoteIdentifier(field.Name) + " IN (" + strings.Join(quoted, ", ") + "))"
+}
+
+// addForeignKeyStatement returns the ALTER TABLE statement adding a foreign key to table.
+func addForeignKeyStatement(dialect Dialect, table *Table, foreign ForeignKey) string {
+	return "ALTER TABLE " + dialect.QuoteIdentifier(table.Name) + " ADD " + foreignKeyClause(table, foreign, dialect) + ";"
+}
+
+// quoteIdentifiers returns the comma-separated list of names quoted by dialect.
+func quoteIdentifiers(dialect Dialect, names []string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, dialect.QuoteIdentifier(name))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// rebindNumbered replaces the "?" placeholders in query with prefix followed by the 1-based index of the placeholder.
+// Generated queries never contain "?" in literals, so every "?" is a placeholder.
+func rebindNumbered(query string, prefix string) string {
+	pieces := strings.Split(query, "?")
+	result := pieces[0]
+	for i, piece := range pieces[1:] {
+		result += prefix + strconv.Itoa(i+1) + piece
+	}
+	return result
+}
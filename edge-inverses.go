@@ -0,0 +1,148 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"sort"
+
+	"github.com/hoop33/go-elvis"
+)
+
+// pairEdges pairs each edge with the edge of its peer on the other side, which is named by the inverse tag
+// or found by the peer type if it is the only candidate.
+// The parent pointers of each child are paired with the fields of the parent containing the child, which decide their types,
+// and multi-multi edges declaring each other as inverse share a single auxiliary table.
+func (schema *Schema) pairEdges() {
+	tables := make([]*MainTable, 0, len(schema.Tables))
+	for _, table := range schema.Tables {
+		tables = append(tables, table)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Name < tables[j].Name
+	})
+
+	for _, table := range tables {
+		schema.pairParentEdges(table)
+		for _, edge := range table.Edges {
+			if edge.Type == EdgeTypeMultiMulti && edge.inverse != "" {
+				schema.pairMultiMultiEdge(table, edge)
+			}
+		}
+	}
+}
+
+// containingEdges returns the edges of parent containing child without pointers.
+func containingEdges(parent *MainTable, child *MainTable) []*Edge {
+	var edges []*Edge
+	for _, edge := range parent.Edges {
+		if edge.PeerTable == child.Name && (edge.Type == EdgeTypeOneMulti || edge.Type == EdgeTypeOneOne) {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+func (schema *Schema) pairParentEdges(table *MainTable) {
+	var siblings []*Edge
+	if table.knownParent != nil {
+		siblings = containingEdges(table.knownParent, table)
+	}
+	paired := map[*Edge]*Edge{}
+	for _, edge := range table.Edges {
+		if edge.Type != EdgeTypeUnknownParent {
+			continue
+		}
+		peer, exists := schema.Tables[edge.PeerTable]
+		if !exists {
+			continue // reported by computeEdges
+		}
+		if peer != table.knownParent {
+			schema.reportField(SeverityError, table, edge.Name, "parent", "type %[1]s does not contain %[2]s, but is declared as parent in %[2]s.%[3]s", peer.Type.Name(), table.Type.Name(), edge.Name)
+			continue
+		}
+
+		var inverse *Edge
+		if edge.inverse != "" {
+			for _, sibling := range siblings {
+				if sibling.Name == edge.inverse {
+					inverse = sibling
+				}
+			}
+			if inverse == nil {
+				schema.reportField(SeverityError, table, edge.Name, "inverse", "type %s has no field %s containing %s", peer.Type.Name(), edge.inverse, table.Type.Name())
+				continue
+			}
+		} else {
+			for _, sibling := range siblings {
+				if sibling.inverse == edge.Name {
+					inverse = sibling
+				}
+			}
+			if inverse == nil && len(siblings) == 1 {
+				inverse = siblings[0]
+			}
+			if inverse == nil {
+				schema.reportField(SeverityError, table, edge.Name, "parent", "type %s contains %s in more than one field; name one of them with the inverse tag", peer.Type.Name(), table.Type.Name())
+				continue
+			}
+		}
+		if inverse.inverse != "" && inverse.inverse != edge.Name {
+			schema.reportField(SeverityError, table, edge.Name, "inverse", "%s.%s declares %s.%s as its inverse", peer.Type.Name(), inverse.Name, table.Type.Name(), inverse.inverse)
+			continue
+		}
+		if other, exists := paired[inverse]; exists {
+			schema.reportField(SeverityError, table, edge.Name, "inverse", "%s.%s is already the parent of the models in %s.%s", table.Type.Name(), other.Name, peer.Type.Name(), inverse.Name)
+			continue
+		}
+		paired[inverse] = edge
+		edge.inverse = inverse.Name
+		edge.Type = elvis.Ternary(inverse.Type == EdgeTypeOneMulti, EdgeTypeMultiOneParent, EdgeTypeOneOneParent).(EdgeType)
+		inverse.inverse = edge.Name
+	}
+
+	for _, sibling := range siblings {
+		if _, exists := paired[sibling]; exists {
+			continue
+		}
+		if sibling.inverse != "" {
+			schema.reportField(SeverityError, table.knownParent, sibling.Name, "inverse", "type %s has no parent pointer %s", table.Type.Name(), sibling.inverse)
+		} else if len(siblings) > 1 {
+			schema.reportField(SeverityError, table.knownParent, sibling.Name, "",
+				"type %[1]s is contained in more than one field of %[2]s, so it must declare a parent pointer to %[2]s with inverse:%[3]q", table.Type.Name(), table.knownParent.Type.Name(), sibling.Name)
+		}
+	}
+}
+
+func (schema *Schema) pairMultiMultiEdge(table *MainTable, edge *Edge) {
+	peer, exists := schema.Tables[edge.PeerTable]
+	if !exists {
+		return // reported by computeEdges
+	}
+	inverse := peer.FindEdgeByName(edge.inverse)
+	if inverse == nil || inverse.Type != EdgeTypeMultiMulti || inverse.PeerTable != table.Name || inverse == edge {
+		schema.reportField(SeverityError, table, edge.Name, "inverse", "type %s has no other slice %s of pointers to %s", peer.Type.Name(), edge.inverse, table.Type.Name())
+		return
+	}
+	if inverse.inverse != edge.Name {
+		schema.reportField(SeverityError, table, edge.Name, "inverse", "%s.%s must declare inverse:%q too", peer.Type.Name(), inverse.Name, edge.Name)
+		return
+	}
+	// the auxiliary table is generated for the edge whose table and name sort first
+	edge.secondary = table.Name > peer.Name || table.Name == peer.Name && edge.Name > inverse.Name
+}
@@ -19,15 +19,24 @@
 package myModel
 
 import (
-	"errors"
 	"fmt"
 	"github.com/hoop33/go-elvis"
+	"reflect"
+	"strings"
 )
 
 type Edge struct {
 	Name      string
 	PeerTable string
 	Type      EdgeType
+	column    string          // name of the field as a column, which prefixes the foreign key columns and names the auxiliary table
+	deferred  bool            // whether the foreign key closes a reference cycle, see deferCycles
+	onDelete  ReferenceOption // from the onDelete tag, empty for the default option of the edge type
+	onUpdate  ReferenceOption // from the onUpdate tag, empty for the default option of the edge type
+	inverse   string          // name of the edge of the peer on the other side, from the inverse tag or paired by pairEdges
+	secondary bool            // whether the auxiliary table of a multi-multi edge is generated for its inverse instead
+	ordered   bool            // whether the children of a one-multi edge store their positions in the slice
+	nullable  bool            // whether the columns of a multi-one edge are nullable, from the nullable tag
 }
 
 //go:generate go-stringer-inverse -linecomment -trimprefix=EdgeType -type=EdgeType
@@ -43,124 +52,307 @@ const (
 	EdgeTypeUnknownParent
 )
 
-func (schema *Schema) computeEdges() error {
+// ImplicitParentEdge names the edge added to a child table whose model has no field referencing its parent.
+const ImplicitParentEdge = "Parent__"
+
+// computeEdges adds the columns, foreign keys and auxiliary tables implementing the edges of each table.
+// Problems are reported to schema.diagnostics, and the offending edges are skipped.
+func (schema *Schema) computeEdges() {
 	for _, table := range schema.getSortedTables() {
 		edges := make([]*Edge, 0, len(table.Edges)+1)
 		for _, edge := range table.Edges {
 			edges = append(edges, edge)
 		}
+		var siblings []*Edge // the fields of the parent containing the model
 		if table.knownParent != nil {
-			if table.FindEdgeByPeerTable(table.knownParent.Name) == nil {
+			siblings = containingEdges(table.knownParent, table)
+			if !table.hasParentEdge() && len(siblings) == 1 {
 				edges = append(edges, &Edge{
-					Name:      "Parent__",
-					Type:      elvis.Ternary(table.knownParent.FindEdgeByPeerTable(table.Name).Type == EdgeTypeOneMulti, EdgeTypeMultiOneParent, EdgeTypeOneOneParent).(EdgeType),
+					Name:      ImplicitParentEdge,
+					Type:      elvis.Ternary(siblings[0].Type == EdgeTypeOneMulti, EdgeTypeMultiOneParent, EdgeTypeOneOneParent).(EdgeType),
 					PeerTable: table.knownParent.Name,
+					column:    schema.naming.ColumnName(ImplicitParentEdge),
+					inverse:   siblings[0].Name,
 				})
 			}
 		}
 		for _, edge := range edges {
-			peer := schema.mustGetTable(edge.PeerTable)
-
-			if edge.Type == EdgeTypeUnknownParent {
-				edge.Type = elvis.Ternary(table.knownParent.FindEdgeByPeerTable(table.Name).Type == EdgeTypeOneMulti, EdgeTypeMultiOneParent, EdgeTypeOneOneParent).(EdgeType)
+			peer, exists := schema.Tables[edge.PeerTable]
+			if !exists {
+				schema.reportField(SeverityError, table, edge.Name, "", "unknown type %s", edge.PeerTable)
+				continue
 			}
 
 			switch edge.Type {
+			case EdgeTypeUnknownParent:
+				// not paired with a field of the parent, reported by pairEdges
+
 			case EdgeTypeMultiMulti:
-				aux := NewTable(table.Name + "_" + edge.Name)
-				foreign := MakeForeignKey(peer.Name)
-				for _, key := range table.PrimaryKeys {
-					originalField := *table.FindField(key)
-					field := originalField
-					field.Name = table.Name + "_" + field.Name
-					field.AutoIncrement = false
-					foreign.SourceColumns = append(foreign.SourceColumns, field.Name)
-					foreign.RefColumns = append(foreign.RefColumns, key)
-					aux.SimpleFields = append(aux.SimpleFields, &field)
-					aux.PrimaryKeys = append(aux.PrimaryKeys, field.Name)
-				}
-				for _, key := range peer.PrimaryKeys {
-					originalField := *table.FindField(key)
-					field := originalField
-					field.Name = peer.Name + "_" + field.Name
-					field.AutoIncrement = false
-					foreign.SourceColumns = append(foreign.SourceColumns, field.Name)
-					foreign.RefColumns = append(foreign.RefColumns, key)
+				if edge.secondary {
+					continue // stored in the auxiliary table of the inverse edge
+				}
+				tableKeys, tableOk := schema.keyFields(table, table, edge.Name)
+				peerKeys, peerOk := schema.keyFields(peer, table, edge.Name)
+				if !tableOk || !peerOk || !schema.checkReferenceOptions(table, edge, false) {
+					continue
+				}
+				aux := NewTable(table.Name + "_" + edge.column)
+				aux.Options = table.Options
+				aux.Options.Comment = "" // the comment describes the model
+				peerColumn := schema.modelColumn(peer)
+				if peer == table {
+					peerColumn = edge.column // both sets of columns would be named after the model
+				}
+				for _, side := range []struct {
+					table  *MainTable
+					keys   []*MysqlField
+					column string
+				}{{table, tableKeys, schema.modelColumn(table)}, {peer, peerKeys, peerColumn}} {
+					foreign := MakeForeignKey(side.table.Name)
 					foreign.OnUpdate = ReferenceOptionCascade
 					foreign.OnDelete = ReferenceOptionCascade
-					aux.SimpleFields = append(aux.SimpleFields, &field)
-					aux.PrimaryKeys = append(aux.PrimaryKeys, field.Name)
+					if side.table == peer {
+						foreign.OnDelete, foreign.OnUpdate = edge.referenceOptions(ReferenceOptionCascade)
+					}
+					foreign.Deferred = side.table == peer && edge.deferred
+					for i, key := range side.table.PrimaryKeys {
+						field := keyColumn(side.keys[i], side.column+"_"+side.keys[i].Name)
+						foreign.SourceColumns = append(foreign.SourceColumns, field.Name)
+						foreign.RefColumns = append(foreign.RefColumns, key)
+						aux.SimpleFields = append(aux.SimpleFields, field)
+						aux.PrimaryKeys = append(aux.PrimaryKeys, field.Name)
+					}
+					aux.ForeignKeys = append(aux.ForeignKeys, foreign)
 				}
 				table.AuxTables = append(table.AuxTables, aux)
 
-			case EdgeTypeMultiOneParent:
-				if edge := peer.FindEdgeByPeerTable(table.Name); edge == nil || edge.Type != EdgeTypeOneMulti {
-					return errors.New(fmt.Sprintf("type %[1]s does not contain %[2]s in a non-pointer slice, but is declared as slice parent in %[2]s.%[3]s", peer.Name, table.Name, edge.Name))
-				}
-				fallthrough
-			case EdgeTypeMultiOne:
+			case EdgeTypeMultiOneParent, EdgeTypeMultiOne:
 				keys := peer.PrimaryKeys
 				if len(peer.PrimaryKeys) == 0 {
-					return errors.New("cannot reference type " + peer.Name + " by pointer in " + table.Name + "." + edge.Name + " because it does not have primary keys")
+					schema.reportField(SeverityError, table, edge.Name, "", "cannot reference type %s by pointer in %s.%s because it does not have primary keys", peer.Type.Name(), table.Type.Name(), edge.Name)
+					continue
+				}
+				peerKeys, ok := schema.keyFields(peer, table, edge.Name)
+				// the root of a tree references no row, each child contained in more than one field belongs to one of them,
+				// and the first row inserted into a reference cycle cannot reference a row yet
+				nullable := edge.nullable || peer == table || edge.Type == EdgeTypeMultiOneParent && len(siblings) > 1 || edge.deferred
+				if !ok || !schema.checkReferenceOptions(table, edge, nullable) {
+					continue
 				}
 				foreign := MakeForeignKey(peer.Name)
-				for _, key := range keys {
-					originalField := *peer.FindField(key) // the definition is in the peer
-					field := originalField
-					field.Name = edge.Name + "_" + field.Name
-					field.AutoIncrement = false
+				if edge.Name != ImplicitParentEdge {
+					foreign.Edge = edge.Name
+				}
+				foreign.Deferred = edge.deferred
+				if edge.Type == EdgeTypeMultiOneParent {
+					foreign.OnDelete, foreign.OnUpdate = edge.referenceOptions(ReferenceOptionCascade)
+				} else {
+					foreign.OnDelete, foreign.OnUpdate = edge.referenceOptions(elvis.Ternary(nullable, ReferenceOptionSetNull, ReferenceOptionRestrict).(ReferenceOption))
+				}
+				for i, key := range keys {
+					field := keyColumn(peerKeys[i], edge.column+"_"+peerKeys[i].Name)
 					foreign.SourceColumns = append(foreign.SourceColumns, field.Name)
 					foreign.RefColumns = append(foreign.RefColumns, key)
-					if edge.Type == EdgeTypeMultiOneParent {
-						foreign.OnUpdate = ReferenceOptionCascade
-						foreign.OnDelete = ReferenceOptionCascade
-					} else {
-						foreign.OnUpdate = elvis.Ternary(field.Nullable, ReferenceOptionSetNull, ReferenceOptionRestrict).(ReferenceOption)
-						foreign.OnDelete = elvis.Ternary(field.Nullable, ReferenceOptionSetNull, ReferenceOptionRestrict).(ReferenceOption)
-					}
-					table.SimpleFields = append(table.SimpleFields, &field)
+					field.Nullable = nullable
+					table.SimpleFields = append(table.SimpleFields, field)
 				}
 				table.ForeignKeys = append(table.ForeignKeys, foreign)
+				if edge.Type == EdgeTypeMultiOneParent {
+					container := &childContainer{parent: peer, field: edge.inverse, multi: true, foreign: foreign}
+					if slice := peer.FindEdgeByName(edge.inverse); slice.ordered {
+						container.position = schema.addChildPosition(table, slice, foreign, nullable)
+					}
+					table.containers = append(table.containers, container)
+				}
 
 			case EdgeTypeOneMulti:
 				// no need to populate anything here
 
 			case EdgeTypeOneOneParent:
-				if edge := peer.FindEdgeByPeerTable(table.Name); edge == nil || edge.Type != EdgeTypeOneOne {
-					return errors.New(fmt.Sprintf("type %[1]s does not contain %[2]s directly, but is declared as single parent in %[2]s.%[3]s", peer.Name, table.Name, edge.Name))
-				}
 				// fallthrough
 				// case EdgeTypeOneOne:
 				keys := peer.PrimaryKeys
 				if len(peer.PrimaryKeys) == 0 {
-					return errors.New("cannot reference type " + peer.Name + " by pointer in " + table.Name + "." + edge.Name + " because it does not have primary keys")
+					schema.reportField(SeverityError, table, edge.Name, "", "cannot reference type %s by pointer in %s.%s because it does not have primary keys", peer.Type.Name(), table.Type.Name(), edge.Name)
+					continue
+				}
+				peerKeys, ok := schema.keyFields(peer, table, edge.Name)
+				nullable := len(siblings) > 1
+				if !ok || !schema.checkReferenceOptions(table, edge, nullable) {
+					continue
 				}
 				foreign := MakeForeignKey(peer.Name)
-				for _, key := range keys {
-					var originalField MysqlField
-					if edge.Type == EdgeTypeOneOneParent {
-						originalField = *peer.FindField(key) // copy the definition from the peer
-					} else {
-						originalField = *table.FindField(key) // the definition has not been copied yet; just use the local one
-					}
-					field := originalField
-					field.Name = peer.Name + "_" + field.Name
-					field.AutoIncrement = false
+				if edge.Name != ImplicitParentEdge {
+					foreign.Edge = edge.Name
+				}
+				foreign.OnDelete, foreign.OnUpdate = edge.referenceOptions(ReferenceOptionCascade)
+				prefix := schema.modelColumn(peer)
+				if len(siblings) > 1 {
+					prefix = edge.column // the columns of each field containing the model are distinct
+				}
+				for i, key := range keys {
+					field := keyColumn(peerKeys[i], prefix+"_"+peerKeys[i].Name)
 					foreign.SourceColumns = append(foreign.SourceColumns, field.Name)
 					foreign.RefColumns = append(foreign.RefColumns, key)
-					if edge.Type == EdgeTypeOneOneParent {
-						foreign.OnUpdate = ReferenceOptionCascade
-						foreign.OnDelete = ReferenceOptionCascade
-					} else {
-						foreign.OnUpdate = elvis.Ternary(field.Nullable, ReferenceOptionSetNull, ReferenceOptionRestrict).(ReferenceOption)
-						foreign.OnDelete = elvis.Ternary(field.Nullable, ReferenceOptionSetNull, ReferenceOptionRestrict).(ReferenceOption)
-					}
-					table.SimpleFields = append(table.SimpleFields, &field)
+					field.Nullable = nullable
+					table.SimpleFields = append(table.SimpleFields, field)
 				}
 				table.ForeignKeys = append(table.ForeignKeys, foreign)
+				table.containers = append(table.containers, &childContainer{parent: peer, field: edge.inverse, foreign: foreign})
 			}
 		}
+
+		for _, list := range table.ValueLists {
+			if len(table.PrimaryKeys) == 0 {
+				schema.reportField(SeverityError, table, list.Name, "", "cannot store slice %s.%s because %s does not have primary keys", table.Type.Name(), list.Name, table.Type.Name())
+				continue
+			}
+			tableKeys, ok := schema.keyFields(table, table, list.Name)
+			if !ok {
+				continue
+			}
+			aux := NewTable(table.Name + "_" + list.column)
+			aux.Options = table.Options
+			aux.Options.Comment = "" // the comment describes the model
+			foreign := MakeForeignKey(table.Name)
+			foreign.OnUpdate = ReferenceOptionCascade
+			foreign.OnDelete = ReferenceOptionCascade
+			for i, key := range table.PrimaryKeys {
+				field := keyColumn(tableKeys[i], schema.modelColumn(table)+"_"+tableKeys[i].Name)
+				foreign.SourceColumns = append(foreign.SourceColumns, field.Name)
+				foreign.RefColumns = append(foreign.RefColumns, key)
+				aux.SimpleFields = append(aux.SimpleFields, field)
+			}
+			if list.Ordered {
+				ordinal := &MysqlField{
+					Name:   schema.naming.ColumnName(ValueListOrdinalColumn),
+					Type:   "INT UNSIGNED",
+					GoType: "int",
+				}
+				aux.SimpleFields = append(aux.SimpleFields, ordinal)
+				aux.PrimaryKeys = append(aux.PrimaryKeys, foreign.SourceColumns...)
+				aux.PrimaryKeys = append(aux.PrimaryKeys, ordinal.Name)
+			}
+			value := *list.Value
+			aux.SimpleFields = append(aux.SimpleFields, &value)
+			aux.ForeignKeys = append(aux.ForeignKeys, foreign)
+			table.AuxTables = append(table.AuxTables, aux)
+			list.Table = aux
+		}
+	}
+}
+
+// keyColumn returns a copy of key, which is a primary key of its table, named name to reference it from another table.
+// The column properties that only describe the key in its own table, namely its comment,
+// the default values and auto increment, do not apply to the referencing column.
+func keyColumn(key *MysqlField, name string) *MysqlField {
+	field := *key
+	field.Name = name
+	field.AutoIncrement = false
+	field.Default, field.OnUpdate, field.Comment = "", "", ""
+	field.GoName = ""
+	return &field
+}
+
+// keyFields returns the fields of the primary keys of table.
+// If any of them is not a column, an error is reported on the edge of referrer and false is returned.
+func (schema *Schema) keyFields(table *MainTable, referrer *MainTable, edge string) ([]*MysqlField, bool) {
+	fields := make([]*MysqlField, 0, len(table.PrimaryKeys))
+	for _, key := range table.PrimaryKeys {
+		field := table.FindField(key)
+		if field == nil {
+			schema.reportField(SeverityError, referrer, edge, "", "primary key %s of %s is not a column", key, table.Type.Name())
+			return nil, false
+		}
+		fields = append(fields, field)
+	}
+	return fields, true
+}
+
+// modelColumn returns the name of the model of table as a column, which prefixes the columns referencing table in the tables derived from the models.
+func (schema *Schema) modelColumn(table *MainTable) string {
+	return schema.naming.ColumnName(table.Type.Name())
+}
+
+// addChildPosition adds the column storing the position of each row of table in an ordered slice of the parent,
+// which is unique among the rows referencing the same parent row through foreign, and returns its name.
+// It is signed so that the positions can be negated while the slice is reordered.
+func (schema *Schema) addChildPosition(table *MainTable, slice *Edge, foreign ForeignKey, nullable bool) string {
+	position := &MysqlField{
+		Name:     slice.column + "_" + schema.naming.ColumnName(ChildPositionColumn),
+		Type:     "INT SIGNED",
+		Nullable: nullable,
+		GoType:   elvis.Ternary(nullable, "*int", "int").(string),
+	}
+	table.SimpleFields = append(table.SimpleFields, position)
+	table.UniqueKeys[position.Name] = append(append([]string{}, foreign.SourceColumns...), position.Name)
+	return position.Name
+}
+
+// edgeReferenceOptions parses the onDelete and onUpdate tags of a field, which are empty if the tags are absent.
+// The onUpdate tag of a simple field is the ON UPDATE clause of its column instead.
+// If a tag is invalid, an error is reported and false is returned.
+func (schema *Schema) edgeReferenceOptions(table *MainTable, field string, tag reflect.StructTag, isComplex bool, isPointer bool) (ReferenceOption, ReferenceOption, bool) {
+	options := [2]ReferenceOption{}
+	for i, name := range [...]string{"onDelete", "onUpdate"} {
+		value, exists := tag.Lookup(name)
+		if !exists || !isComplex && name == "onUpdate" {
+			continue
+		}
+		if !isComplex || !isPointer {
+			schema.reportField(SeverityError, table, field, name, "the %s tag is only supported on pointers to models; declare it on the parent pointer of a contained model", name)
+			return "", "", false
+		}
+		option, err := parseReferenceOption(value)
+		if err != nil {
+			schema.reportField(SeverityError, table, field, name, "%v", err)
+			return "", "", false
+		}
+		options[i] = option
 	}
+	return options[0], options[1], true
+}
+
+func parseReferenceOption(value string) (ReferenceOption, error) {
+	switch strings.ToLower(value) {
+	case "cascade":
+		return ReferenceOptionCascade, nil
+	case "restrict":
+		return ReferenceOptionRestrict, nil
+	case "setnull":
+		return ReferenceOptionSetNull, nil
+	case "noaction":
+		return ReferenceOptionNoAction, nil
+	}
+	return "", fmt.Errorf("unknown reference option %q, expected cascade, restrict, setnull or noaction", value)
+}
 
-	return nil
+// referenceOptions returns the ON DELETE and ON UPDATE options of the foreign key implementing edge,
+// which are fallback and updateFallback(fallback) unless they are set by the tags.
+func (edge *Edge) referenceOptions(fallback ReferenceOption) (ReferenceOption, ReferenceOption) {
+	return elvis.Ternary(edge.onDelete != "", edge.onDelete, fallback).(ReferenceOption),
+		elvis.Ternary(edge.onUpdate != "", edge.onUpdate, updateFallback(fallback)).(ReferenceOption)
+}
+
+// updateFallback returns the default ON UPDATE option of a foreign key whose default ON DELETE option is onDelete.
+// Updating a referenced key does not remove the row, so the references are not cleared by default.
+func updateFallback(onDelete ReferenceOption) ReferenceOption {
+	return elvis.Ternary(onDelete == ReferenceOptionSetNull, ReferenceOptionRestrict, onDelete).(ReferenceOption)
+}
+
+// checkReferenceOptions reports an error and returns false if the tags of edge set SET NULL, but the columns of its foreign key are not nullable.
+func (schema *Schema) checkReferenceOptions(table *MainTable, edge *Edge, nullable bool) bool {
+	if nullable {
+		return true
+	}
+	for _, option := range [...]struct {
+		tag   string
+		value ReferenceOption
+	}{{"onDelete", edge.onDelete}, {"onUpdate", edge.onUpdate}} {
+		if option.value == ReferenceOptionSetNull {
+			schema.reportField(SeverityError, table, edge.Name, option.tag, "SET NULL requires nullable columns, but the columns referencing %s are NOT NULL; add the nullable tag", edge.PeerTable)
+			return false
+		}
+	}
+	return true
 }
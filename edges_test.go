@@ -0,0 +1,204 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// foreignKey returns the foreign key of table on the given columns, or fails the test if there is none.
+func foreignKey(t *testing.T, table *Table, columns ...string) ForeignKey {
+	t.Helper()
+	for _, foreign := range table.ForeignKeys {
+		if stringSlicesEqual(foreign.SourceColumns, columns) {
+			return foreign
+		}
+	}
+	t.Fatalf("%s has no foreign key on %v", table.Name, columns)
+	return ForeignKey{}
+}
+
+func TestSelfReference(t *testing.T) {
+	_, _, models := sourceModels(t, repositoryModels, "User")
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	user := schema.Tables["User"]
+	if field := user.FindField("Manager_Id"); field == nil || !field.Nullable {
+		t.Errorf("expected the column referencing the same table to be nullable, got %+v", field)
+	}
+	if foreign := foreignKey(t, user.Table, "Manager_Id"); foreign.OnDelete != ReferenceOptionSetNull || foreign.OnUpdate != ReferenceOptionRestrict || foreign.Deferred {
+		t.Errorf("expected ON UPDATE RESTRICT ON DELETE SET NULL in CREATE TABLE, got %+v", foreign)
+	}
+
+	generated := &strings.Builder{}
+	if err := schema.OutputGo(GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", GoStream: generated}); err != nil {
+		t.Fatalf("output Go: %v", err)
+	}
+	for _, method := range []string{"ManagerAncestors", "ManagerDescendants"} {
+		start := strings.Index(generated.String(), "func (repo *UserRepository) "+method+"(")
+		if start < 0 {
+			t.Errorf("expected the method %s to be generated", method)
+			continue
+		}
+		body := generated.String()[start:]
+		body = body[:strings.Index(body, "\n}\n")]
+		if !strings.Contains(body, fmt.Sprintf("related.depth_ < %d", MaxTreeDepth)) {
+			t.Errorf("expected %s to stop after %d levels, got\n%s", method, MaxTreeDepth, body)
+		}
+	}
+}
+
+const multipleEdgeModels = `package models
+
+type Account struct {
+	Id     uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Inbox  []Message
+	Outbox []Message
+	Groups []*Group ` + "`inverse:\"Members\"`" + `
+	Owned  []*Group ` + "`inverse:\"Owners\"`" + `
+}
+
+type Message struct {
+	Id        uint32   ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Recipient *Account ` + "`parent:\"\" inverse:\"Inbox\"`" + `
+	Sender    *Account ` + "`parent:\"\" inverse:\"Outbox\"`" + `
+	Cc        *Account ` + "`nullable:\"\"`" + `
+}
+
+type Group struct {
+	Id      uint32     ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Members []*Account ` + "`inverse:\"Groups\"`" + `
+	Owners  []*Account ` + "`inverse:\"Owned\"`" + `
+}
+`
+
+func TestMultipleEdgesToPeer(t *testing.T) {
+	fset, file, models := sourceModels(t, multipleEdgeModels, "Account")
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	generated := &strings.Builder{}
+	if err := schema.OutputGo(GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", GoStream: generated}); err != nil {
+		t.Fatalf("output Go: %v", err)
+	}
+	checkGeneratedGo(t, fset, file, generated.String())
+
+	message := schema.Tables["Message"]
+	for name, expected := range map[string]EdgeType{"Recipient": EdgeTypeMultiOneParent, "Sender": EdgeTypeMultiOneParent, "Cc": EdgeTypeMultiOne} {
+		if edge := message.FindEdgeByName(name); edge == nil || edge.Type != expected || edge.PeerTable != "Account" {
+			t.Errorf("expected Message.%s to be a %v edge to Account, got %+v", name, expected, edge)
+		} else if field := message.FindField(name + "_Id"); field == nil || !field.Nullable {
+			t.Errorf("expected %s_Id to be nullable, got %+v", name, field)
+		}
+	}
+
+	auxTables := []string{}
+	for _, aux := range schema.Tables["Account"].AuxTables {
+		auxTables = append(auxTables, aux.Name)
+	}
+	if !stringSlicesEqual(auxTables, []string{"Account_Groups", "Account_Owned"}) || len(schema.Tables["Group"].AuxTables) != 0 {
+		t.Errorf("expected the inverse slices of Group to share the auxiliary tables of Account, got %v and %d tables of Group", auxTables, len(schema.Tables["Group"].AuxTables))
+	}
+}
+
+// TestReferenceOptionTags checks the reference options of a pointer with the given tags,
+// and that SET NULL is only accepted on nullable columns.
+func TestReferenceOptionTags(t *testing.T) {
+	for _, test := range []struct {
+		tags     string
+		onDelete ReferenceOption // empty if an error is expected
+		onUpdate ReferenceOption
+		nullable bool
+	}{
+		{``, ReferenceOptionRestrict, ReferenceOptionRestrict, false},
+		{`nullable:""`, ReferenceOptionSetNull, ReferenceOptionRestrict, true},
+		{`onDelete:"cascade" onUpdate:"cascade"`, ReferenceOptionCascade, ReferenceOptionCascade, false},
+		{`onDelete:"setnull"`, "", "", false},
+		{`nullable:"" onDelete:"setnull"`, ReferenceOptionSetNull, ReferenceOptionRestrict, true},
+		{`onUpdate:"setnull"`, "", "", false},
+		{`nullable:"" onUpdate:"setnull"`, ReferenceOptionSetNull, ReferenceOptionSetNull, true},
+		{`nullable:"" onDelete:"cascade"`, ReferenceOptionCascade, ReferenceOptionRestrict, true},
+		{`onDelete:"bogus"`, "", "", false},
+	} {
+		source := fmt.Sprintf("package models\n\ntype Note struct {\n\tId uint32 `primaryKey:\"\"`\n\tAuthor *Person `%s`\n}\n\ntype Person struct {\n\tId uint32 `primaryKey:\"\"`\n}\n", test.tags)
+		_, _, models := sourceModels(t, source, "Note")
+		schema, err := BuildSchemaFromTypes(models)
+		if test.onDelete == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.tags)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.tags, err)
+			continue
+		}
+		note := schema.Tables["Note"]
+		if foreign := foreignKey(t, note.Table, "Author_Id"); foreign.OnDelete != test.onDelete || foreign.OnUpdate != test.onUpdate {
+			t.Errorf("%s: expected ON UPDATE %s ON DELETE %s, got ON UPDATE %s ON DELETE %s", test.tags, test.onUpdate, test.onDelete, foreign.OnUpdate, foreign.OnDelete)
+		}
+		if field := note.FindField("Author_Id"); field.Nullable != test.nullable {
+			t.Errorf("%s: expected Author_Id to be nullable: %v", test.tags, test.nullable)
+		}
+	}
+}
+
+func TestNullableTagOnSimpleField(t *testing.T) {
+	_, _, models := sourceModels(t, "package models\n\ntype Note struct {\n\tId uint32 `primaryKey:\"\"`\n\tCount *int32 `nullable:\"\"`\n}\n", "Note")
+	if _, err := BuildSchemaFromTypes(models); err == nil || !strings.Contains(err.Error(), "the nullable tag is only supported on pointers to models") {
+		t.Errorf("expected the nullable tag on a simple field to be rejected, got %v", err)
+	}
+}
+
+func TestValueLists(t *testing.T) {
+	source := "package models\n\ntype Row struct {\n\tId uint32 `primaryKey:\"\"`\n\tTags []string `width:\"16\" ordered:\"\"`\n\tScores []*int32\n}\n"
+	_, _, models := sourceModels(t, source, "Row")
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	aux := map[string]*Table{}
+	for _, table := range schema.Tables["Row"].AuxTables {
+		aux[table.Name] = table
+	}
+
+	tags := aux["Row_Tags"]
+	if tags == nil || !stringSlicesEqual(tags.PrimaryKeys, []string{"Row_Id", ValueListOrdinalColumn}) {
+		t.Fatalf("expected the ordered auxiliary table Row_Tags keyed by the row and the ordinal, got %+v", tags)
+	}
+	if value := tags.FindField(ValueListValueColumn); value == nil || value.Type != "VARCHAR(16)" || value.Nullable {
+		t.Errorf("expected the NOT NULL value column VARCHAR(16), got %+v", value)
+	}
+
+	scores := aux["Row_Scores"]
+	if scores == nil || len(scores.PrimaryKeys) != 0 || scores.FindField(ValueListOrdinalColumn) != nil {
+		t.Fatalf("expected the unordered auxiliary table Row_Scores without ordinals, got %+v", scores)
+	}
+	if value := scores.FindField(ValueListValueColumn); value == nil || value.Type != "INT SIGNED" || !value.Nullable {
+		t.Errorf("expected the nullable value column INT SIGNED, got %+v", value)
+	}
+	if foreign := foreignKey(t, scores, "Row_Id"); foreign.RefTable != "Row" || foreign.OnDelete != ReferenceOptionCascade {
+		t.Errorf("expected the values to be deleted with the row, got %+v", foreign)
+	}
+}
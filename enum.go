@@ -0,0 +1,114 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	enumRegistry     = map[reflect.Type][]string{}
+	enumRegistryLock sync.RWMutex
+)
+
+// RegisterEnum declares the values allowed for a named string or integer type,
+// which are used for the fields of the type with an empty enum tag.
+// The columns of integer types store the values named by the integers, which are their indices in values.
+//
+// This is only required for types passed to Generate as reflect types.
+// When the models are loaded from source code, the values of string types are the typed constants of the type declared in its package,
+// while the values of integer types must be listed in the enum tag.
+func RegisterEnum(typ reflect.Type, values ...string) {
+	enumRegistryLock.Lock()
+	defer enumRegistryLock.Unlock()
+	enumRegistry[typ] = values
+}
+
+func registeredEnumValues(typ reflect.Type) []string {
+	enumRegistryLock.RLock()
+	defer enumRegistryLock.RUnlock()
+	return enumRegistry[typ]
+}
+
+// enumValues returns the values allowed for a field with the enum tag, which lists the values,
+// or takes them from the type of the field if it is empty.
+// It returns nil if the field is not an enum.
+func enumValues(typ TypeInfo, tag reflect.StructTag) ([]string, error) {
+	values, exists := tag.Lookup("enum")
+	if !exists {
+		return nil, nil
+	}
+	if typ.Kind() != reflect.String && !isOrdinalEnum(typ) {
+		return nil, errors.New("enum fields must be strings or integers")
+	}
+	if values != "" {
+		split := strings.Split(values, ",")
+		for i, value := range split {
+			split[i] = strings.TrimSpace(value)
+		}
+		return split, nil
+	}
+	if typeValues := typ.EnumValues(); len(typeValues) > 0 {
+		return typeValues, nil
+	}
+	return nil, fmt.Errorf("no enum values are known for type %s", typ)
+}
+
+// isOrdinalEnum reports whether enum values are represented by values of typ as their indices, which is the case for integers.
+func isOrdinalEnum(typ TypeInfo) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// enumMysqlType returns the type of an ENUM or SET column with the given values.
+func enumMysqlType(base string, values []string) (string, error) {
+	if len(values) == 0 {
+		return "", errors.New("enum types must have at least one value")
+	}
+	seen := map[string]bool{}
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		if seen[value] {
+			return "", fmt.Errorf("duplicate enum value %q", value)
+		}
+		seen[value] = true
+		if base == "SET" && strings.ContainsRune(value, ',') {
+			return "", fmt.Errorf("SET value %q must not contain commas", value)
+		}
+		quoted = append(quoted, quoteSqlString(value))
+	}
+	return base + "(" + strings.Join(quoted, ",") + ")", nil
+}
+
+func quoteSqlString(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
+
+// isSetType reports whether mysqlType is a SET type, which is stored as a comma-separated string.
+func isSetType(mysqlType string) bool {
+	return strings.HasPrefix(mysqlType, "SET(")
+}
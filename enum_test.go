@@ -0,0 +1,75 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type enumTestString string
+
+type enumTestLevel uint8
+
+type enumTestRegistered string
+
+func TestEnumValues(t *testing.T) {
+	RegisterEnum(reflect.TypeOf(enumTestRegistered("")), "on", "off")
+
+	for _, test := range []struct {
+		typ      reflect.Type
+		tag      reflect.StructTag
+		expected []string // nil if an error is expected
+	}{
+		{reflect.TypeOf(enumTestString("")), `enum:"a, b ,c"`, []string{"a", "b", "c"}},
+		{reflect.TypeOf(enumTestLevel(0)), `enum:"low,mid,high"`, []string{"low", "mid", "high"}},
+		{reflect.TypeOf(int64(0)), `enum:" low "`, []string{"low"}},
+		{reflect.TypeOf(enumTestRegistered("")), `enum:""`, []string{"on", "off"}},
+		{reflect.TypeOf(enumTestLevel(0)), `enum:""`, nil},
+		{reflect.TypeOf(float64(0)), `enum:"low"`, nil},
+	} {
+		values, err := enumValues(ReflectType(test.typ), test.tag)
+		if test.expected == nil && err == nil {
+			t.Errorf("%s `%s`: expected an error, got %q", test.typ, test.tag, values)
+		} else if test.expected != nil && (err != nil || !reflect.DeepEqual(values, test.expected)) {
+			t.Errorf("%s `%s`: expected %q, got %q (%v)", test.typ, test.tag, test.expected, values, err)
+		}
+	}
+}
+
+func TestIntegerEnumColumns(t *testing.T) {
+	_, _, models := sourceModels(t, repositoryModels, "User")
+	sql := outputSql(t, models, MysqlDialect{})
+	for _, expected := range []string{"`Level`       ENUM('low','mid','high') NOT NULL", "`Levels`      SET('low','mid','high')  NOT NULL"} {
+		if !strings.Contains(sql, expected) {
+			t.Errorf("expected the DDL to contain %q, got\n%s", expected, sql)
+		}
+	}
+
+	generated := &strings.Builder{}
+	if err := GenerateTypes(GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", SqlStream: &strings.Builder{}, GoStream: generated}, models); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	for _, expected := range []string{"if uint64(entity.Level) >= 3 {", "encodePriority(entity.Level)", "decodePrioritySet(scanLevels)"} {
+		if !strings.Contains(generated.String(), expected) {
+			t.Errorf("expected the repositories to contain %q, got\n%s", expected, generated.String())
+		}
+	}
+}
@@ -31,6 +31,20 @@ type GeneratorConfig struct {
 
 	SqlStream io.Writer
 	GoStream  io.Writer
+
+	Previous        *Schema   // the schema currently deployed, if migrations are to be generated, usually from LoadSchema
+	MigrationStream io.Writer // receives the statements migrating Previous to the generated schema
+	SnapshotStream  io.Writer // receives the snapshot of the generated schema, if not nil
+
+	DiagnosticStream io.Writer // receives the warnings about the models, if not nil; errors are returned instead
+
+	Dialect Dialect        // the database the SQL and Go code are generated for, MysqlDialect if nil
+	Types   *TypeRegistry  // mappings of Go types to columns, in addition to the built-in ones
+	Naming  NamingStrategy // names of the tables and columns of the models, DefaultNaming if nil
+
+	// TableOptions are the options of every table, unless overridden by the model.
+	// Changes to these options are not migrated, since snapshots only record the options declared by the models.
+	TableOptions TableOptions
 }
 
 func (config *GeneratorConfig) WriteSql(s string) error {
@@ -0,0 +1,1167 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hoop33/go-elvis"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const goQueryerDeclaration = `// Queryer is implemented by both *sql.DB and *sql.Tx.
+type Queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+`
+
+// MaxTreeDepth is the number of levels loaded by the methods returning the ancestors and descendants of a row,
+// which stops the recursive queries if the references form a cycle. It is below the default recursion limit of MySQL.
+const MaxTreeDepth = 500
+
+// goWriter buffers generated Go code and remembers the imports it refers to.
+type goWriter struct {
+	bytes.Buffer
+	imports map[string]bool
+	dialect Dialect
+	helpers map[string]string // functions shared by the repositories, by name
+}
+
+func (writer *goWriter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(writer, format, args...)
+}
+
+// query returns the Go literal of a query, with the placeholders of the dialect.
+func (writer *goWriter) query(query string) string {
+	return strconv.Quote(writer.dialect.Rebind(query))
+}
+
+func (writer *goWriter) typeName(field *MysqlField) string {
+	if field.GoImport != "" {
+		writer.imports[field.GoImport] = true
+	}
+	return field.GoType
+}
+
+// goCodec describes how the Go value of a column is converted to and from the value stored in the database.
+// Its functions are empty if the driver handles the Go value itself.
+type goCodec struct {
+	encode   string // function converting the Go value to the value written
+	decode   string // function converting the scanned value to the Go value
+	scanType string // type of the local variable the column is scanned into before decode
+	scanner  string // function wrapping the address of the Go value into the sql.Scanner it is scanned with, instead of decode
+
+	decodeError bool // whether decode also returns an error
+}
+
+func (writer *goWriter) codec(field *MysqlField) goCodec {
+	switch {
+	case field.GoValuer:
+		return goCodec{}
+	case field.GoMapping != nil && (field.GoMapping.Encode != "" || field.GoMapping.Decode != ""):
+		return writer.mappingCodec(field)
+	case field.GoOrdinal:
+		return writer.ordinalCodec(field)
+	case isSetType(field.Type):
+		encode, decode := writer.setCodec(field)
+		return goCodec{encode: encode, decode: decode, scanType: "string"}
+	case strings.HasPrefix(field.Type, "BINARY("):
+		encode, decode := writer.binaryCodec(field)
+		return goCodec{encode: encode, decode: decode, scanType: "[]byte"}
+	case field.Type == "JSON":
+		return writer.jsonCodec(field)
+	}
+	return goCodec{}
+}
+
+// helper declares a function shared by the repositories, unless it is already declared.
+func (writer *goWriter) helper(name string, format string, args ...interface{}) {
+	if _, exists := writer.helpers[name]; !exists {
+		writer.helpers[name] = fmt.Sprintf(format, args...)
+	}
+}
+
+// setCodec returns the functions converting the slice stored in a SET column to and from its comma-separated string.
+func (writer *goWriter) setCodec(field *MysqlField) (string, string) {
+	elemType := strings.TrimPrefix(writer.typeName(field), "[]")
+	suffix := goExportedName(elemType) + "Set"
+	writer.imports["strings"] = true
+	writer.helper("encode"+suffix, "func encode%s(values []%s) string {\n"+
+		"\tparts := make([]string, 0, len(values))\n"+
+		"\tfor _, value := range values {\n\t\tparts = append(parts, string(value))\n\t}\n"+
+		"\treturn strings.Join(parts, \",\")\n}\n", suffix, elemType)
+	writer.helper("decode"+suffix, "func decode%s(value string) []%s {\n"+
+		"\tvalues := []%s{}\n"+
+		"\tif value == \"\" {\n\t\treturn values\n\t}\n"+
+		"\tfor _, part := range strings.Split(value, \",\") {\n\t\tvalues = append(values, %s(part))\n\t}\n"+
+		"\treturn values\n}\n", suffix, elemType, elemType, elemType)
+	return "encode" + suffix, "decode" + suffix
+}
+
+// ordinalCodec returns the functions converting the integers of an enum type to and from the names of the values they index.
+func (writer *goWriter) ordinalCodec(field *MysqlField) goCodec {
+	typeName := writer.typeName(field)
+	elemType := strings.TrimPrefix(strings.TrimPrefix(typeName, "[]"), "*")
+	suffix := goExportedName(elemType)
+	quoted := make([]string, 0, len(field.Values))
+	for _, value := range field.Values {
+		quoted = append(quoted, strconv.Quote(value))
+	}
+	writer.imports["fmt"] = true
+	writer.helper("names"+suffix, "var names%s = []string{%s}\n", suffix, strings.Join(quoted, ", "))
+	writer.helper("encode"+suffix, "func encode%s(value %s) string {\n\treturn names%s[value]\n}\n", suffix, elemType, suffix)
+	writer.helper("decode"+suffix, "func decode%s(value string) (%s, error) {\n"+
+		"\tfor i, name := range names%s {\n\t\tif name == value {\n\t\t\treturn %s(i), nil\n\t\t}\n\t}\n"+
+		"\treturn 0, fmt.Errorf(\"unknown %s %%q\", value)\n}\n", suffix, elemType, suffix, elemType, elemType)
+	codec := goCodec{encode: "encode" + suffix, decode: "decode" + suffix, scanType: "string", decodeError: true}
+
+	switch {
+	case isSetType(field.Type):
+		writer.imports["strings"] = true
+		writer.helper("encode"+suffix+"Set", "func encode%sSet(values []%s) string {\n"+
+			"\tparts := make([]string, 0, len(values))\n"+
+			"\tfor _, value := range values {\n\t\tparts = append(parts, encode%s(value))\n\t}\n"+
+			"\treturn strings.Join(parts, \",\")\n}\n", suffix, elemType, suffix)
+		writer.helper("decode"+suffix+"Set", "func decode%sSet(value string) ([]%s, error) {\n"+
+			"\tvalues := []%s{}\n"+
+			"\tif value == \"\" {\n\t\treturn values, nil\n\t}\n"+
+			"\tfor _, part := range strings.Split(value, \",\") {\n"+
+			"\t\tdecoded, err := decode%s(part)\n\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n"+
+			"\t\tvalues = append(values, decoded)\n\t}\n"+
+			"\treturn values, nil\n}\n", suffix, elemType, elemType, suffix)
+		codec.encode, codec.decode = "encode"+suffix+"Set", "decode"+suffix+"Set"
+	case strings.HasPrefix(typeName, "*"):
+		writer.helper("encodeNull"+suffix, "func encodeNull%s(value *%s) interface{} {\n"+
+			"\tif value == nil {\n\t\treturn nil\n\t}\n\treturn encode%s(*value)\n}\n", suffix, elemType, suffix)
+		writer.helper("decodeNull"+suffix, "func decodeNull%s(value *string) (*%s, error) {\n"+
+			"\tif value == nil {\n\t\treturn nil, nil\n\t}\n"+
+			"\tdecoded, err := decode%s(*value)\n\treturn &decoded, err\n}\n", suffix, elemType, suffix)
+		codec.encode, codec.decode, codec.scanType = "encodeNull"+suffix, "decodeNull"+suffix, "*string"
+	}
+	return codec
+}
+
+// binaryCodec returns the functions converting a byte array stored in a BINARY column to and from a byte slice,
+// which is the only binary type drivers accept.
+func (writer *goWriter) binaryCodec(field *MysqlField) (string, string) {
+	arrayType := strings.TrimPrefix(writer.typeName(field), "*")
+	suffix := goExportedName(arrayType)
+	if strings.HasPrefix(arrayType, "[") {
+		suffix = "Binary" + strings.TrimSuffix(strings.TrimPrefix(field.Type, "BINARY("), ")")
+	}
+	if !field.Nullable {
+		writer.helper("encode"+suffix, "func encode%s(value %s) []byte {\n\treturn value[:]\n}\n", suffix, arrayType)
+		writer.helper("decode"+suffix, "func decode%s(value []byte) %s {\n"+
+			"\tvar array %s\n\tcopy(array[:], value)\n\treturn array\n}\n", suffix, arrayType, arrayType)
+		return "encode" + suffix, "decode" + suffix
+	}
+	suffix = "Null" + suffix
+	writer.helper("encode"+suffix, "func encode%s(value *%s) interface{} {\n"+
+		"\tif value == nil {\n\t\treturn nil\n\t}\n\treturn value[:]\n}\n", suffix, arrayType)
+	writer.helper("decode"+suffix, "func decode%s(value []byte) *%s {\n"+
+		"\tif value == nil {\n\t\treturn nil\n\t}\n"+
+		"\tarray := new(%s)\n\tcopy(array[:], value)\n\treturn array\n}\n", suffix, arrayType, arrayType)
+	return "encode" + suffix, "decode" + suffix
+}
+
+// mappingCodec returns the functions wrapping the expressions of a registered TypeMapping.
+// Pointers are converted with separate functions, which convert nil from and to NULL.
+func (writer *goWriter) mappingCodec(field *MysqlField) goCodec {
+	mapping := field.GoMapping
+	for _, importPath := range mapping.Imports {
+		writer.imports[importPath] = true
+	}
+	goType := strings.TrimPrefix(writer.typeName(field), "*")
+	suffix := goExportedName(goType)
+	codec := goCodec{scanType: mapping.ScanType, decodeError: mapping.DecodeError}
+	decodeResult := elvis.Ternary(mapping.DecodeError, "(%s, error)", "%s").(string)
+	if mapping.Encode != "" {
+		writer.helper("encode"+suffix, "func encode%s(value %s) interface{} {\n\treturn %s\n}\n", suffix, goType, mapping.Encode)
+		codec.encode = "encode" + suffix
+	}
+	if mapping.Decode != "" {
+		writer.helper("decode"+suffix, "func decode%s(value %s) "+decodeResult+" {\n\treturn %s\n}\n", suffix, mapping.ScanType, goType, mapping.Decode)
+		codec.decode = "decode" + suffix
+	}
+	if !strings.HasPrefix(field.GoType, "*") {
+		return codec
+	}
+
+	if codec.encode != "" {
+		writer.helper("encodeNull"+suffix, "func encodeNull%s(value *%s) interface{} {\n"+
+			"\tif value == nil {\n\t\treturn nil\n\t}\n\treturn encode%s(*value)\n}\n", suffix, goType, suffix)
+		codec.encode = "encodeNull" + suffix
+	}
+	if codec.decode != "" {
+		if mapping.DecodeError {
+			writer.helper("decodeNull"+suffix, "func decodeNull%s(value *%s) (*%s, error) {\n"+
+				"\tif value == nil {\n\t\treturn nil, nil\n\t}\n"+
+				"\tdecoded, err := decode%s(*value)\n\treturn &decoded, err\n}\n", suffix, mapping.ScanType, goType, suffix)
+		} else {
+			writer.helper("decodeNull"+suffix, "func decodeNull%s(value *%s) *%s {\n"+
+				"\tif value == nil {\n\t\treturn nil\n\t}\n"+
+				"\tdecoded := decode%s(*value)\n\treturn &decoded\n}\n", suffix, mapping.ScanType, goType, suffix)
+		}
+		codec.decode = "decodeNull" + suffix
+		codec.scanType = "*" + mapping.ScanType
+	}
+	return codec
+}
+
+// jsonCodec returns the functions marshalling the value of a JSON column, which is written as NULL if field is nullable and the value is nil.
+func (writer *goWriter) jsonCodec(field *MysqlField) goCodec {
+	writer.imports["database/sql/driver"] = true
+	writer.imports["encoding/json"] = true
+	writer.imports["fmt"] = true
+	writer.helper("jsonValue", "// jsonValue converts a value to and from a JSON column. When scanning, value is the address of the value.\n"+
+		"type jsonValue struct {\n\tvalue    interface{}\n\tnullable bool\n}\n\n"+
+		"func (value jsonValue) Value() (driver.Value, error) {\n"+
+		"\tdata, err := json.Marshal(value.value)\n"+
+		"\tif err != nil || value.nullable && string(data) == \"null\" {\n\t\treturn nil, err\n\t}\n"+
+		"\treturn string(data), nil\n}\n\n"+
+		"func (value jsonValue) Scan(src interface{}) error {\n"+
+		"\tswitch src := src.(type) {\n"+
+		"\tcase nil:\n\t\treturn json.Unmarshal([]byte(\"null\"), value.value)\n"+
+		"\tcase []byte:\n\t\treturn json.Unmarshal(src, value.value)\n"+
+		"\tcase string:\n\t\treturn json.Unmarshal([]byte(src), value.value)\n\t}\n"+
+		"\treturn fmt.Errorf(\"cannot scan %%T into a JSON value\", src)\n}\n")
+	writer.helper("scanJSON", "func scanJSON(value interface{}) jsonValue {\n\treturn jsonValue{value: value}\n}\n")
+	if field.Nullable {
+		writer.helper("encodeNullJSON", "func encodeNullJSON(value interface{}) jsonValue {\n\treturn jsonValue{value: value, nullable: true}\n}\n")
+		return goCodec{encode: "encodeNullJSON", scanner: "scanJSON"}
+	}
+	writer.helper("encodeJSON", "func encodeJSON(value interface{}) jsonValue {\n\treturn jsonValue{value: value}\n}\n")
+	return goCodec{encode: "encodeJSON", scanner: "scanJSON"}
+}
+
+// decodeStatements returns the statements using the decoded value of scanned in the statement format use,
+// which return results if decode returns an error.
+func (codec goCodec) decodeStatements(use string, scanned string, indent string, results string) string {
+	if !codec.decodeError {
+		return indent + fmt.Sprintf(use, codec.decode+"("+scanned+")") + "\n"
+	}
+	name := strings.TrimPrefix(scanned, "*")
+	decoded := "decoded" + strings.ToUpper(name[:1]) + name[1:]
+	return fmt.Sprintf("%s%s, err := %s(%s)\n%sif err != nil {\n%s\treturn %s\n%s}\n%s%s\n",
+		indent, decoded, codec.decode, scanned, indent, indent, results, indent, indent, fmt.Sprintf(use, decoded))
+}
+
+// goColumn describes how a column of a main table is read from and written to its model.
+type goColumn struct {
+	*MysqlField
+	selector string // selector relative to the model value, empty if the column is not backed by the model
+	edge     string // the pointer field that must be allocated before selector can be assigned
+	peerType string // the model type pointed to by edge
+	local    string // name of the parameter or local variable holding the value
+	goCodec
+}
+
+func (column *goColumn) backed() bool {
+	return column.selector != ""
+}
+
+// scanned returns the local variable a column referencing another model or requiring decode is scanned into.
+func (column *goColumn) scanned() string {
+	return "scan" + strings.ToUpper(column.local[:1]) + column.local[1:]
+}
+
+// edgeScanPointer returns "*" if a column referencing another model is scanned into a pointer to its scanType,
+// because scanType cannot hold NULL.
+func (column *goColumn) edgeScanPointer() string {
+	if column.edge == "" || strings.HasPrefix(column.scanType, "[]") || strings.HasPrefix(column.scanType, "*") {
+		return ""
+	}
+	return "*"
+}
+
+// value returns the expression passed to the driver when the column is written.
+func (column *goColumn) value() string {
+	if column.edge != "" {
+		return column.local // encoded by outputGoEdgeValues
+	}
+	if !column.backed() {
+		return column.encoded(column.local)
+	}
+	return column.encoded("entity." + column.selector)
+}
+
+// encoded returns the expression passed to the driver for expr, which is a value of the Go type of the column.
+func (column *goColumn) encoded(expr string) string {
+	if column.encode != "" {
+		return column.encode + "(" + expr + ")"
+	}
+	return expr
+}
+
+// OutputGo writes the repositories of the models to config.GoStream.
+// If the rows contained in a field of a model cannot be saved and loaded by its repository, the field is skipped with a warning in Schema.Diagnostics.
+func (schema *Schema) OutputGo(config GeneratorConfig) error {
+	body := &goWriter{imports: map[string]bool{"context": true, "database/sql": true}, dialect: config.dialect(), helpers: map[string]string{}}
+	body.WriteString(goQueryerDeclaration)
+	for _, table := range schema.getSortedTables() {
+		if table.Type == nil {
+			return fmt.Errorf("table %s has no model", table.Name)
+		}
+		_, problems := schema.goChildren(table, schema.goColumns(table))
+		schema.diagnostics = append(schema.diagnostics, problems...)
+		schema.outputGoRepository(table, body)
+	}
+
+	return body.writeFile(config, "// Code generated by my-model. DO NOT EDIT.")
+}
+
+// writeFile writes the buffered code to config.GoStream as a formatted file in config.Package.
+func (writer *goWriter) writeFile(config GeneratorConfig, header string) error {
+	imports := make([]string, 0, len(writer.imports))
+	for importPath := range writer.imports {
+		imports = append(imports, importPath)
+	}
+	sort.Strings(imports)
+
+	file := &bytes.Buffer{}
+	fmt.Fprintf(file, "%s\n\npackage %s\n\n", header, config.Package)
+	if len(imports) > 0 {
+		file.WriteString("import (\n")
+		for _, importPath := range imports {
+			fmt.Fprintf(file, "\t%s\n", strconv.Quote(importPath))
+		}
+		file.WriteString(")\n\n")
+	}
+	file.Write(writer.Bytes())
+	helperNames := make([]string, 0, len(writer.helpers))
+	for name := range writer.helpers {
+		helperNames = append(helperNames, name)
+	}
+	sort.Strings(helperNames)
+	for _, name := range helperNames {
+		fmt.Fprintf(file, "\n%s", writer.helpers[name])
+	}
+
+	source, err := format.Source(file.Bytes())
+	if err != nil {
+		return fmt.Errorf("generated Go code is invalid: %v", err)
+	}
+	return config.WriteGo(string(source))
+}
+
+func (schema *Schema) goColumns(table *MainTable) []*goColumn {
+	columns := make([]*goColumn, 0, len(table.SimpleFields))
+	for _, field := range table.SimpleFields {
+		if field.JsonPath != nil {
+			continue // computed by the database
+		}
+		column := &goColumn{MysqlField: field, selector: field.GoName, local: goLocalName(field.Name)}
+		for _, foreign := range table.ForeignKeys {
+			if foreign.Edge == "" {
+				continue
+			}
+			for i, source := range foreign.SourceColumns {
+				if source != field.Name {
+					continue
+				}
+				peer := schema.Tables[foreign.RefTable]
+				if ref := peer.FindField(foreign.RefColumns[i]); ref != nil && ref.GoName != "" {
+					column.selector = foreign.Edge + "." + ref.GoName
+					column.edge = foreign.Edge
+					column.peerType = peer.Type.Name()
+				}
+			}
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// goColumnGroups are the columns of a main table grouped by how the repository reads and writes them.
+type goColumnGroups struct {
+	primaryKeys []*goColumn // in the order of table.PrimaryKeys
+	backedKeys  []*goColumn
+	backed      []*goColumn
+	unbacked    []*goColumn
+	updated     []*goColumn // the backed columns written by Update
+}
+
+func goGroupColumns(table *MainTable, columns []*goColumn) goColumnGroups {
+	var groups goColumnGroups
+	for _, column := range columns {
+		isPrimary := false
+		for _, key := range table.PrimaryKeys {
+			if key == column.Name {
+				isPrimary = true
+			}
+		}
+		if column.backed() {
+			groups.backed = append(groups.backed, column)
+			if isPrimary {
+				groups.backedKeys = append(groups.backedKeys, column)
+			} else if column.OnUpdate == "" {
+				groups.updated = append(groups.updated, column) // columns with ON UPDATE are set by the database
+			}
+		} else {
+			groups.unbacked = append(groups.unbacked, column)
+		}
+	}
+	for _, key := range table.PrimaryKeys {
+		for _, column := range columns {
+			if column.Name == key {
+				groups.primaryKeys = append(groups.primaryKeys, column)
+			}
+		}
+	}
+	return groups
+}
+
+// hasKeys returns whether the rows can be identified by the model, which is required to read and update them.
+func (groups goColumnGroups) hasKeys() bool {
+	return len(groups.primaryKeys) > 0 && len(groups.backedKeys) == len(groups.primaryKeys)
+}
+
+// goHasUpdate returns whether the repository of table has an Update method.
+func (schema *Schema) goHasUpdate(table *MainTable) bool {
+	columns := schema.goColumns(table)
+	groups := goGroupColumns(table, columns)
+	if !groups.hasKeys() {
+		return false
+	}
+	children, _ := schema.goChildren(table, columns)
+	return len(groups.updated) > 0 || len(table.ValueLists) > 0 || len(children) > 0
+}
+
+func (schema *Schema) outputGoRepository(table *MainTable, writer *goWriter) {
+	name := table.Type.Name()
+	columns := schema.goColumns(table)
+	for _, column := range columns {
+		column.goCodec = writer.codec(column.MysqlField)
+	}
+
+	groups := goGroupColumns(table, columns)
+	primaryKeys, backed, unbacked, updated := groups.primaryKeys, groups.backed, groups.unbacked, groups.updated
+
+	writer.printf("\n// %sRepository reads and writes %s rows in the %s table.\n", name, name, table.Name)
+	writer.printf("type %sRepository struct {\n\tDB Queryer\n}\n", name)
+
+	// Insert
+	params := ""
+	for _, column := range unbacked {
+		params += ", " + column.local + " " + writer.typeName(column.MysqlField)
+	}
+	inserted := make([]*goColumn, 0, len(columns))
+	var defaulted []*goColumn
+	var autoIncrement *goColumn
+	for _, column := range columns {
+		if column.AutoIncrement && column.backed() && column.edge == "" && !column.Nullable {
+			autoIncrement = column
+			continue
+		}
+		if column.Default != "" && column.backed() && column.edge == "" && strings.HasPrefix(column.GoType, "*") {
+			defaulted = append(defaulted, column)
+			continue
+		}
+		inserted = append(inserted, column)
+	}
+	hasKeys := groups.hasKeys()
+	lists := table.ValueLists
+	var children []*goChildren
+	if hasKeys {
+		children, _ = schema.goChildren(table, columns) // the problems are reported by OutputGo
+	} else {
+		lists = nil
+	}
+
+	writer.printf("\n")
+	if len(defaulted) > 0 {
+		writer.printf("// Insert leaves out the columns with defaults whose pointers are nil, so that the database fills in the defaults.\n")
+	}
+	writer.printf("func (repo *%sRepository) Insert(ctx context.Context, entity *%s%s) error {\n", name, name, params)
+	outputGoEdgeValues(inserted, writer)
+	outputGoEnumChecks(name, inserted, writer)
+	outputGoEnumChecks(name, defaulted, writer)
+	tableName := writer.dialect.QuoteIdentifier(table.Name)
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", tableName, goColumnNames(inserted, ", ", writer.dialect), goPlaceholders(len(inserted)))
+	returning := ""
+	if autoIncrement != nil && writer.dialect.InsertReturning() {
+		returning = " RETURNING " + writer.dialect.QuoteIdentifier(autoIncrement.Name)
+	}
+	queryExpr, valuesExpr := writer.query(query+returning), goColumnValues(inserted)
+	if len(defaulted) > 0 {
+		queryExpr, valuesExpr = outputGoDefaultedInsert(tableName, returning, inserted, defaulted, writer), ", values..."
+	}
+	if autoIncrement == nil {
+		writer.printf("\tif _, err := repo.DB.ExecContext(ctx, %s%s); err != nil {\n\t\treturn err\n\t}\n", queryExpr, valuesExpr)
+	} else if returning != "" {
+		writer.printf("\tif err := repo.DB.QueryRowContext(ctx, %s%s).Scan(&entity.%s); err != nil {\n\t\treturn err\n\t}\n", queryExpr, valuesExpr, autoIncrement.selector)
+	} else {
+		writer.printf("\tresult, err := repo.DB.ExecContext(ctx, %s%s)\n\tif err != nil {\n\t\treturn err\n\t}\n", queryExpr, valuesExpr)
+		writer.printf("\tinsertId, err := result.LastInsertId()\n\tif err != nil {\n\t\treturn err\n\t}\n")
+		writer.printf("\tentity.%s = %s(insertId)\n", autoIncrement.selector, writer.typeName(autoIncrement.MysqlField))
+	}
+	outputGoSaveValueLists(lists, primaryKeys, writer)
+	outputGoSaveChildren(children, writer)
+	writer.printf("\treturn nil\n}\n")
+
+	if !hasKeys {
+		return
+	}
+
+	keyParams := make([]string, 0, len(primaryKeys))
+	keyArgs := ""
+	keyConditions := make([]string, 0, len(primaryKeys))
+	for _, column := range primaryKeys {
+		keyParams = append(keyParams, column.local+" "+writer.typeName(column.MysqlField))
+		keyArgs += ", " + column.encoded(column.local)
+		keyConditions = append(keyConditions, writer.dialect.QuoteIdentifier(column.Name)+" = ?")
+	}
+	where := " WHERE " + strings.Join(keyConditions, " AND ")
+
+	// GetByPrimaryKey
+	writer.printf("\nfunc (repo *%sRepository) GetByPrimaryKey(ctx context.Context, %s) (*%s, error) {\n", name, strings.Join(keyParams, ", "), name)
+	writer.printf("\tentity := &%s{}\n", name)
+	targets := make([]string, 0, len(backed))
+	for _, column := range backed {
+		if column.edge != "" && column.decode == "" {
+			writer.printf("\tvar %s *%s\n", column.scanned(), writer.typeName(column.MysqlField))
+			targets = append(targets, "&"+column.scanned())
+		} else if column.decode != "" {
+			writer.printf("\tvar %s %s%s\n", column.scanned(), column.edgeScanPointer(), column.scanType)
+			targets = append(targets, "&"+column.scanned())
+		} else if column.scanner != "" {
+			targets = append(targets, column.scanner+"(&entity."+column.selector+")")
+		} else {
+			targets = append(targets, "&entity."+column.selector)
+		}
+	}
+	query = fmt.Sprintf("SELECT %s FROM %s", goColumnNames(backed, ", ", writer.dialect), tableName) + where
+	writer.printf("\tif err := repo.DB.QueryRowContext(ctx, %s%s).Scan(%s); err != nil {\n\t\treturn nil, err\n\t}\n",
+		writer.query(query), keyArgs, strings.Join(targets, ", "))
+	for _, column := range backed {
+		if column.edge != "" {
+			// the scanned value is nil if the edge is NULL; byte slices are nil for NULL too
+			writer.printf("\tif %s != nil {\n", column.scanned())
+			writer.printf("\t\tif entity.%s == nil {\n\t\t\tentity.%s = &%s{}\n\t\t}\n", column.edge, column.edge, column.peerType)
+			if column.decode != "" {
+				writer.printf("%s\t}\n", column.decodeStatements("entity."+column.selector+" = %s", column.edgeScanPointer()+column.scanned(), "\t\t", "nil, err"))
+			} else {
+				writer.printf("\t\tentity.%s = *%s\n\t}\n", column.selector, column.scanned())
+			}
+		} else if column.decode != "" {
+			writer.printf("%s", column.decodeStatements("entity."+column.selector+" = %s", column.scanned(), "\t", "nil, err"))
+		}
+	}
+	for _, list := range lists {
+		writer.printf("\tvalues%s, err := repo.load%s(ctx%s)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n", list.Name, list.Name, keyArgs)
+		writer.printf("\tentity.%s = values%s\n", list.Name, list.Name)
+	}
+	for _, children := range children {
+		writer.printf("\tif err := repo.load%s(ctx, entity); err != nil {\n\t\treturn nil, err\n\t}\n", children.container.field)
+	}
+	writer.printf("\treturn entity, nil\n}\n")
+
+	// Update
+	if len(updated) > 0 || len(lists) > 0 || len(children) > 0 {
+		writer.printf("\nfunc (repo *%sRepository) Update(ctx context.Context, entity *%s) error {\n", name, name)
+		outputGoEdgeValues(backed, writer)
+		outputGoEnumChecks(name, updated, writer)
+		if len(updated) > 0 {
+			query = fmt.Sprintf("UPDATE %s SET %s", tableName, goColumnNames(updated, " = ?, ", writer.dialect)+" = ?") + where
+			writer.printf("\tif _, err := repo.DB.ExecContext(ctx, %s%s%s); err != nil {\n\t\treturn err\n\t}\n", writer.query(query), goColumnValues(updated), goColumnValues(primaryKeys))
+		}
+		outputGoSaveValueLists(lists, primaryKeys, writer)
+		outputGoSaveChildren(children, writer)
+		writer.printf("\treturn nil\n}\n")
+	}
+
+	// Delete, which also deletes the rows of value lists through their foreign keys
+	query = "DELETE FROM " + tableName + where
+	writer.printf("\nfunc (repo *%sRepository) Delete(ctx context.Context, %s) error {\n", name, strings.Join(keyParams, ", "))
+	writer.printf("\t_, err := repo.DB.ExecContext(ctx, %s%s)\n\treturn err\n}\n", writer.query(query), keyArgs)
+
+	hasTree := outputGoTreeMethods(table, primaryKeys, keyParams, keyArgs, writer)
+	for _, container := range table.containers {
+		outputGoContainerMethods(table, container, columns, primaryKeys, writer)
+	}
+	if hasTree || len(table.containers) > 0 {
+		outputGoLoadSelected(table, primaryKeys, writer)
+	}
+
+	for _, list := range lists {
+		outputGoValueListMethods(table, list, writer)
+	}
+	for _, children := range children {
+		schema.outputGoChildMethods(table, children, writer)
+	}
+}
+
+// goChildren describes how the repository of a parent writes and reads the rows contained in one of its fields
+// through the repository of the children.
+type goChildren struct {
+	child         *MainTable
+	container     *childContainer
+	parentPointer string   // the field of the children pointing to the parent, which is set before they are written, if any
+	conditions    []string // the conditions selecting the rows of the field
+	keys          []string // the values of the parent keys in the conditions
+	insertArgs    []string // the arguments of the Insert method of the children, after the entity
+	loadArgs      []string // the arguments of the Load method of the children
+}
+
+// goChildren returns the fields of table containing children whose rows can be written and read by its repository,
+// and a diagnostic for each of the other fields.
+func (schema *Schema) goChildren(table *MainTable, columns []*goColumn) ([]*goChildren, Diagnostics) {
+	var result []*goChildren
+	var problems Diagnostics
+	for _, child := range schema.getSortedTables() {
+		for _, container := range child.containers {
+			if container.parent != table {
+				continue
+			}
+			children := &goChildren{child: child, container: container}
+			if reason := schema.resolveGoChildren(children, columns); reason != "" {
+				problems = append(problems, newFieldDiagnostic(SeverityWarning, table, container.field, "",
+					"the generated repository of %s does not save and load the rows in this field, because %s", table.Type.Name(), reason))
+				continue
+			}
+			result = append(result, children)
+		}
+	}
+	return result, problems
+}
+
+// resolveGoChildren fills in how the rows of children are written and read, given the columns of the parent.
+// The rows are identified by the primary keys of the children, which must be comparable fields of their models,
+// and the columns of the children not backed by their models must be the keys of the parent, the position or nullable.
+// It returns the reason why the rows cannot be written and read, or an empty string.
+func (schema *Schema) resolveGoChildren(children *goChildren, columns []*goColumn) string {
+	child, container := children.child, children.container
+	if len(child.PrimaryKeys) == 0 {
+		return fmt.Sprintf("%s has no primary keys to identify its rows", child.Type.Name())
+	}
+	for _, key := range child.PrimaryKeys {
+		if field := child.FindField(key); field == nil || field.GoName == "" || strings.HasPrefix(field.GoType, "[]") {
+			// the keys of the stored rows are compared with those of the models
+			return fmt.Sprintf("the primary key %s of %s is not a comparable field of the model", key, child.Type.Name())
+		}
+	}
+	for i, source := range container.foreign.SourceColumns {
+		for _, parentColumn := range columns {
+			if parentColumn.Name == container.foreign.RefColumns[i] && parentColumn.backed() {
+				pointer := elvis.Ternary(strings.HasPrefix(child.FindField(source).GoType, "*"), "&", "").(string)
+				children.loadArgs = append(children.loadArgs, pointer+"entity."+parentColumn.selector)
+				children.keys = append(children.keys, parentColumn.encoded("entity."+parentColumn.selector))
+				children.conditions = append(children.conditions, source)
+			}
+		}
+	}
+	if len(children.keys) != len(container.foreign.SourceColumns) {
+		return fmt.Sprintf("the primary keys of %s are not fields of the model", container.parent.Type.Name())
+	}
+
+	for _, column := range schema.goColumns(child) {
+		isSource := false
+		for i, source := range container.foreign.SourceColumns {
+			if source != column.Name {
+				continue
+			}
+			isSource = true
+			if !column.backed() {
+				for _, parentColumn := range columns {
+					if parentColumn.Name == container.foreign.RefColumns[i] {
+						pointer := elvis.Ternary(strings.HasPrefix(column.GoType, "*"), "&", "").(string)
+						children.insertArgs = append(children.insertArgs, pointer+"entity."+parentColumn.selector)
+					}
+				}
+			}
+		}
+		switch {
+		case column.backed():
+			if isSource && column.edge != "" {
+				children.parentPointer = column.edge
+			}
+		case isSource:
+		case column.Name == container.position:
+			children.insertArgs = append(children.insertArgs, elvis.Ternary(strings.HasPrefix(column.GoType, "*"), "&i", "i").(string))
+		case column.Nullable:
+			children.insertArgs = append(children.insertArgs, "nil") // another edge of a child contained in more than one field
+		default:
+			return fmt.Sprintf("%s has the NOT NULL column %s, which is not a field of the model", child.Type.Name(), column.Name)
+		}
+	}
+	return ""
+}
+
+func outputGoSaveChildren(children []*goChildren, writer *goWriter) {
+	for _, children := range children {
+		writer.printf("\tif err := repo.save%s(ctx, entity); err != nil {\n\t\treturn err\n\t}\n", children.container.field)
+	}
+}
+
+// outputGoChildMethods writes the methods saving and loading the rows contained in a field of the model.
+// The rows are matched with the stored ones by their primary keys, so that the rows kept in the field keep their keys
+// and the rows referencing them are not affected. The rows removed from the field are deleted.
+func (schema *Schema) outputGoChildMethods(table *MainTable, children *goChildren, writer *goWriter) {
+	name := table.Type.Name()
+	childName := children.child.Type.Name()
+	childTable := writer.dialect.QuoteIdentifier(children.child.Name)
+	field := children.container.field
+	multi := children.container.multi
+	element := "entity." + field
+	if multi {
+		element += "[i]"
+	}
+	conditions := make([]string, 0, len(children.conditions))
+	for _, column := range children.conditions {
+		conditions = append(conditions, writer.dialect.QuoteIdentifier(column)+" = ?")
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+	childColumns := schema.goColumns(children.child)
+	for _, column := range childColumns {
+		column.goCodec = writer.codec(column.MysqlField)
+	}
+	keyColumns := goGroupColumns(children.child, childColumns).primaryKeys
+	keyType := goKeyType(children.child)
+	keyConditions := make([]string, 0, len(keyColumns))
+	keyFields := make([]string, 0, len(keyColumns))
+	storedArgs, elementArgs := "", ""
+	for _, column := range keyColumns {
+		keyConditions = append(keyConditions, writer.dialect.QuoteIdentifier(column.Name)+" = ?")
+		keyFields = append(keyFields, column.local+": "+element+"."+column.selector)
+		storedArgs += ", " + column.encoded("key."+column.local)
+		elementArgs += ", " + column.encoded(element+"."+column.selector)
+	}
+	elementKey := keyType + "{" + strings.Join(keyFields, ", ") + "}"
+	keyWhere := " WHERE " + strings.Join(keyConditions, " AND ")
+	parentArgs := ", " + strings.Join(children.keys, ", ")
+
+	writer.printf("\nfunc (repo *%sRepository) save%s(ctx context.Context, entity *%s) error {\n", name, field, name)
+	writer.printf("\tchildren := &%sRepository{DB: repo.DB}\n", childName)
+	query := fmt.Sprintf("SELECT %s FROM %s%s", goColumnNames(keyColumns, ", ", writer.dialect), childTable, where)
+	writer.printf("\tstored, err := children.selectKeys(ctx, %s%s)\n\tif err != nil {\n\t\treturn err\n\t}\n", writer.query(query), parentArgs)
+	if multi {
+		writer.printf("\tkept := map[%s]bool{}\n\tfor i := range entity.%s {\n\t\tkept[%s] = true\n\t}\n", keyType, field, elementKey)
+	} else {
+		writer.printf("\tkept := map[%s]bool{%s: true}\n", keyType, elementKey)
+	}
+	writer.printf("\texisting := map[%s]bool{}\n\tfor _, key := range stored {\n", keyType)
+	writer.printf("\t\tif kept[key] {\n\t\t\texisting[key] = true\n")
+	writer.printf("\t\t} else if _, err := repo.DB.ExecContext(ctx, %s%s); err != nil {\n\t\t\treturn err\n\t\t}\n\t}\n", writer.query("DELETE FROM "+childTable+keyWhere), storedArgs)
+	if children.container.position != "" {
+		// the positions are negated first, so that no two rows have the same position while they are updated one by one
+		column := writer.dialect.QuoteIdentifier(children.container.position)
+		query = fmt.Sprintf("UPDATE %s SET %s = -%s - 1%s", childTable, column, column, where)
+		writer.printf("\tif _, err := repo.DB.ExecContext(ctx, %s%s); err != nil {\n\t\treturn err\n\t}\n", writer.query(query), parentArgs)
+	}
+
+	insert := fmt.Sprintf("children.Insert(ctx, &%s, %s)", element, strings.Join(children.insertArgs, ", "))
+	update := ""
+	if schema.goHasUpdate(children.child) {
+		update = fmt.Sprintf("children.Update(ctx, &%s)", element)
+	}
+	if !multi {
+		if children.parentPointer != "" {
+			writer.printf("\t%s.%s = entity\n", element, children.parentPointer)
+		}
+		writer.printf("\tif !existing[%s] {\n\t\treturn %s\n\t}\n", elementKey, insert)
+		writer.printf("\treturn %s\n}\n", elvis.Ternary(update != "", update, "nil").(string))
+	} else {
+		writer.printf("\tfor i := range entity.%s {\n", field)
+		if children.parentPointer != "" {
+			writer.printf("\t\t%s.%s = entity\n", element, children.parentPointer)
+		}
+		writer.printf("\t\tif !existing[%s] {\n\t\t\tif err := %s; err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\t\tcontinue\n\t\t}\n", elementKey, insert)
+		if update != "" {
+			writer.printf("\t\tif err := %s; err != nil {\n\t\t\treturn err\n\t\t}\n", update)
+		}
+		if children.container.position != "" {
+			query = fmt.Sprintf("UPDATE %s SET %s = ?%s", childTable, writer.dialect.QuoteIdentifier(children.container.position), keyWhere)
+			writer.printf("\t\tif _, err := repo.DB.ExecContext(ctx, %s, i%s); err != nil {\n\t\t\treturn err\n\t\t}\n", writer.query(query), elementArgs)
+		}
+		writer.printf("\t}\n\treturn nil\n}\n")
+	}
+
+	writer.printf("\nfunc (repo *%sRepository) load%s(ctx context.Context, entity *%s) error {\n", name, field, name)
+	writer.printf("\tloaded, err := (&%sRepository{DB: repo.DB}).Load%s%s(ctx, %s)\n\tif err != nil {\n\t\treturn err\n\t}\n",
+		childName, name, field, strings.Join(children.loadArgs, ", "))
+	if multi {
+		writer.printf("\tentity.%s = make([]%s, 0, len(loaded))\n\tfor _, child := range loaded {\n\t\tentity.%s = append(entity.%s, *child)\n\t}\n", field, childName, field, field)
+	} else {
+		writer.printf("\tif len(loaded) > 0 {\n\t\tentity.%s = *loaded[0]\n\t}\n", field)
+	}
+	writer.printf("\treturn nil\n}\n")
+}
+
+// outputGoDefaultedInsert writes the statements building the columns and values of an insert,
+// appending the defaulted columns only if their pointers are not nil.
+// It returns the expression of the query followed by suffix, whose values are in the local "values".
+func outputGoDefaultedInsert(tableName string, suffix string, inserted []*goColumn, defaulted []*goColumn, writer *goWriter) string {
+	names := make([]string, 0, len(inserted))
+	for _, column := range inserted {
+		names = append(names, strconv.Quote(writer.dialect.QuoteIdentifier(column.Name)))
+	}
+	writer.printf("\tcolumns := []string{%s}\n", strings.Join(names, ", "))
+	writer.printf("\tvalues := []interface{}{%s}\n", strings.TrimPrefix(goColumnValues(inserted), ", "))
+	for _, column := range defaulted {
+		writer.printf("\tif entity.%s != nil {\n", column.selector)
+		writer.printf("\t\tcolumns = append(columns, %s)\n", strconv.Quote(writer.dialect.QuoteIdentifier(column.Name)))
+		writer.printf("\t\tvalues = append(values, %s)\n\t}\n", column.value())
+	}
+
+	writer.imports["strings"] = true
+	// the placeholders are counted at runtime, so they are numbered in the same way as the dialect rebinds them
+	placeholder := strconv.Quote("?")
+	if numbered := strings.TrimSuffix(writer.dialect.Rebind("?"), "1"); numbered != "?" {
+		writer.imports["strconv"] = true
+		placeholder = strconv.Quote(numbered) + " + strconv.Itoa(i+1)"
+	}
+	writer.helper("insertPlaceholders", "func insertPlaceholders(count int) string {\n"+
+		"\tplaceholders := make([]string, count)\n\tfor i := range placeholders {\n\t\tplaceholders[i] = %s\n\t}\n"+
+		"\treturn strings.Join(placeholders, \", \")\n}\n", placeholder)
+	return fmt.Sprintf("%s + strings.Join(columns, \", \") + %s + insertPlaceholders(len(values)) + %s",
+		strconv.Quote("INSERT INTO "+tableName+" ("), strconv.Quote(") VALUES ("), strconv.Quote(")"+suffix))
+}
+
+// outputGoTreeMethods writes the methods loading the ancestors and descendants of a row
+// through each reference of the model to itself, using recursive common table expressions.
+// It returns whether the model references itself.
+func outputGoTreeMethods(table *MainTable, primaryKeys []*goColumn, keyParams []string, keyArgs string, writer *goWriter) bool {
+	name := table.Type.Name()
+	tableName := writer.dialect.QuoteIdentifier(table.Name)
+	keys := make([]string, 0, len(primaryKeys))
+	for _, column := range primaryKeys {
+		keys = append(keys, writer.dialect.QuoteIdentifier(column.Name))
+	}
+	hasTree := false
+	for _, foreign := range table.ForeignKeys {
+		if foreign.RefTable != table.Name || foreign.Edge == "" {
+			continue
+		}
+		hasTree = true
+		references := make([]string, 0, len(foreign.SourceColumns))
+		for _, source := range foreign.SourceColumns {
+			references = append(references, writer.dialect.QuoteIdentifier(source))
+		}
+		// the referenced columns are the primary keys in their order, see computeEdges
+		placeholders := strings.Split(strings.Repeat("?", len(keys)), "")
+		notNull := make([]string, 0, len(keys))
+		for _, key := range keys {
+			notNull = append(notNull, key+" IS NOT NULL")
+		}
+		selected := strings.Join(keys, ", ")
+
+		ancestors := fmt.Sprintf("WITH RECURSIVE related (%s, depth_) AS (SELECT %s, 1 FROM %s WHERE %s UNION ALL SELECT %s, related.depth_ + 1 FROM %s AS t JOIN related ON %s WHERE related.depth_ < %d) SELECT %s FROM related WHERE %s ORDER BY depth_",
+			selected, strings.Join(references, ", "), tableName, goEqualities(keys, placeholders),
+			strings.Join(goPrefixed("t.", references), ", "), tableName, goEqualities(goPrefixed("t.", keys), goPrefixed("related.", keys)), MaxTreeDepth,
+			selected, strings.Join(notNull, " AND "))
+		writer.printf("\n// %sAncestors returns the rows referenced through %s, starting from the one referenced by the given row,\n", foreign.Edge, foreign.Edge)
+		writer.printf("// up to %d levels in case the references form a cycle.\n", MaxTreeDepth)
+		writer.printf("// The query requires MySQL 8 or another database supporting recursive common table expressions.\n")
+		writer.printf("func (repo *%sRepository) %sAncestors(ctx context.Context, %s) ([]*%s, error) {\n", name, foreign.Edge, strings.Join(keyParams, ", "), name)
+		writer.printf("\treturn repo.loadSelected(ctx, %s%s)\n}\n", writer.query(ancestors), keyArgs)
+
+		descendants := fmt.Sprintf("WITH RECURSIVE related (%s, depth_) AS (SELECT %s, 1 FROM %s WHERE %s UNION ALL SELECT %s, related.depth_ + 1 FROM %s AS t JOIN related ON %s WHERE related.depth_ < %d) SELECT %s FROM related ORDER BY depth_, %s",
+			selected, selected, tableName, goEqualities(references, placeholders),
+			strings.Join(goPrefixed("t.", keys), ", "), tableName, goEqualities(goPrefixed("t.", references), goPrefixed("related.", keys)), MaxTreeDepth,
+			selected, selected)
+		writer.printf("\n// %sDescendants returns the rows referencing the given row through %s, directly or indirectly, nearest first,\n", foreign.Edge, foreign.Edge)
+		writer.printf("// up to %d levels in case the references form a cycle.\n", MaxTreeDepth)
+		writer.printf("// The query requires MySQL 8 or another database supporting recursive common table expressions.\n")
+		writer.printf("func (repo *%sRepository) %sDescendants(ctx context.Context, %s) ([]*%s, error) {\n", name, foreign.Edge, strings.Join(keyParams, ", "), name)
+		writer.printf("\treturn repo.loadSelected(ctx, %s%s)\n}\n", writer.query(descendants), keyArgs)
+	}
+	return hasTree
+}
+
+// outputGoContainerMethods writes the methods loading the rows contained in a field of the parent,
+// and ordering them if the field is an ordered slice.
+func outputGoContainerMethods(table *MainTable, container *childContainer, columns []*goColumn, primaryKeys []*goColumn, writer *goWriter) {
+	name := table.Type.Name()
+	method := container.parent.Type.Name() + container.field
+	tableName := writer.dialect.QuoteIdentifier(table.Name)
+	params := make([]string, 0, len(container.foreign.SourceColumns))
+	parentArgs := ""
+	parentConditions := make([]string, 0, len(container.foreign.SourceColumns))
+	for _, source := range container.foreign.SourceColumns {
+		for _, reference := range columns {
+			if reference.Name == source {
+				params = append(params, reference.local+" "+writer.typeName(reference.MysqlField))
+				parentArgs += ", " + reference.encoded(reference.local)
+				parentConditions = append(parentConditions, writer.dialect.QuoteIdentifier(source)+" = ?")
+			}
+		}
+	}
+	where := " WHERE " + strings.Join(parentConditions, " AND ")
+
+	keys := goColumnNames(primaryKeys, ", ", writer.dialect)
+	order, orderName := keys, "their primary keys"
+	if container.position != "" {
+		order, orderName = writer.dialect.QuoteIdentifier(container.position), "the slice"
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s", keys, tableName, where, order)
+	writer.printf("\n// Load%s returns the rows in %s.%s, in the order of %s.\n", method, container.parent.Type.Name(), container.field, orderName)
+	writer.printf("func (repo *%sRepository) Load%s(ctx context.Context, %s) ([]*%s, error) {\n", name, method, strings.Join(params, ", "), name)
+	writer.printf("\treturn repo.loadSelected(ctx, %s%s)\n}\n", writer.query(query), parentArgs)
+	if container.position == "" {
+		return
+	}
+
+	column := writer.dialect.QuoteIdentifier(container.position)
+
+	// the positions are negated first, so that no two rows have the same position while they are updated one by one
+	writer.printf("\n// Save%sOrder stores the order of entities as the order of the rows in %s.%s.\n", method, container.parent.Type.Name(), container.field)
+	writer.printf("// Rows missing from entities keep their order after them. It should be called in a transaction.\n")
+	writer.printf("func (repo *%sRepository) Save%sOrder(ctx context.Context, %s, entities []*%s) error {\n", name, method, strings.Join(params, ", "), name)
+	query = fmt.Sprintf("UPDATE %s SET %s = -%s - 1%s", tableName, column, column, where)
+	writer.printf("\tif _, err := repo.DB.ExecContext(ctx, %s%s); err != nil {\n\t\treturn err\n\t}\n", writer.query(query), parentArgs)
+	keyConditions := make([]string, 0, len(primaryKeys))
+	for _, key := range primaryKeys {
+		keyConditions = append(keyConditions, writer.dialect.QuoteIdentifier(key.Name)+" = ?")
+	}
+	writer.printf("\tfor i, entity := range entities {\n")
+	outputGoEdgeValues(primaryKeys, writer)
+	query = fmt.Sprintf("UPDATE %s SET %s = ?%s AND %s", tableName, column, where, strings.Join(keyConditions, " AND "))
+	writer.printf("\t\tif _, err := repo.DB.ExecContext(ctx, %s, i%s%s); err != nil {\n\t\t\treturn err\n\t\t}\n\t}\n", writer.query(query), parentArgs, goColumnValues(primaryKeys))
+	query = fmt.Sprintf("UPDATE %s SET %s = ? - %s%s AND %s < 0", tableName, column, column, where, column)
+	writer.printf("\t_, err := repo.DB.ExecContext(ctx, %s, len(entities) - 1%s)\n\treturn err\n}\n", writer.query(query), parentArgs)
+}
+
+// goKeyType returns the name of the struct type holding the primary keys of a row of table.
+func goKeyType(table *MainTable) string {
+	name := table.Type.Name()
+	return strings.ToLower(name[:1]) + name[1:] + "Key"
+}
+
+// outputGoLoadSelected writes the methods selecting the primary keys of rows and loading the rows whose primary keys are selected by a query.
+func outputGoLoadSelected(table *MainTable, primaryKeys []*goColumn, writer *goWriter) {
+	name := table.Type.Name()
+	keyType := goKeyType(table)
+	writer.printf("\n// %s holds the primary keys of a row in the %s table.\ntype %s struct {\n", keyType, table.Name, keyType)
+	for _, column := range primaryKeys {
+		writer.printf("\t%s %s\n", column.local, writer.typeName(column.MysqlField))
+	}
+	writer.printf("}\n")
+
+	writer.printf("\nfunc (repo *%sRepository) selectKeys(ctx context.Context, query string, args ...interface{}) ([]%s, error) {\n", name, keyType)
+	writer.printf("\trows, err := repo.DB.QueryContext(ctx, query, args...)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\tdefer rows.Close()\n")
+	writer.printf("\tkeys := []%s{}\n\tfor rows.Next() {\n\t\tvar key %s\n", keyType, keyType)
+	targets := make([]string, 0, len(primaryKeys))
+	for _, column := range primaryKeys {
+		if column.decode != "" {
+			writer.printf("\t\tvar %s %s\n", column.scanned(), column.scanType)
+			targets = append(targets, "&"+column.scanned())
+		} else if column.scanner != "" {
+			targets = append(targets, column.scanner+"(&key."+column.local+")")
+		} else {
+			targets = append(targets, "&key."+column.local)
+		}
+	}
+	writer.printf("\t\tif err := rows.Scan(%s); err != nil {\n\t\t\treturn nil, err\n\t\t}\n", strings.Join(targets, ", "))
+	for _, column := range primaryKeys {
+		if column.decode != "" {
+			writer.printf("%s", column.decodeStatements("key."+column.local+" = %s", column.scanned(), "\t\t", "nil, err"))
+		}
+	}
+	writer.printf("\t\tkeys = append(keys, key)\n\t}\n\treturn keys, rows.Err()\n}\n")
+
+	keyValues := make([]string, 0, len(primaryKeys))
+	for _, column := range primaryKeys {
+		keyValues = append(keyValues, "key."+column.local)
+	}
+	// the keys are read before the rows are loaded, since a connection cannot run a query while reading the result of another one
+	writer.printf("\nfunc (repo *%sRepository) loadSelected(ctx context.Context, query string, args ...interface{}) ([]*%s, error) {\n", name, name)
+	writer.printf("\tkeys, err := repo.selectKeys(ctx, query, args...)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	writer.printf("\tentities := make([]*%s, 0, len(keys))\n\tfor _, key := range keys {\n", name)
+	writer.printf("\t\tentity, err := repo.GetByPrimaryKey(ctx, %s)\n\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n", strings.Join(keyValues, ", "))
+	writer.printf("\t\tentities = append(entities, entity)\n\t}\n\treturn entities, nil\n}\n")
+}
+
+func outputGoSaveValueLists(lists []*ValueList, primaryKeys []*goColumn, writer *goWriter) {
+	for _, list := range lists {
+		writer.printf("\tif err := repo.save%s(ctx, entity.%s%s); err != nil {\n\t\treturn err\n\t}\n", list.Name, list.Name, goColumnValues(primaryKeys))
+	}
+}
+
+// outputGoValueListMethods writes the methods replacing and loading the rows of the auxiliary table of a value list.
+// The keys are the primary keys of the owning row, in the order of table.PrimaryKeys,
+// which are the first columns of the auxiliary table, followed by the ordinal column if the list is ordered.
+func outputGoValueListMethods(table *MainTable, list *ValueList, writer *goWriter) {
+	auxName := writer.dialect.QuoteIdentifier(list.Table.Name)
+	keyConditions := make([]string, 0, len(table.PrimaryKeys))
+	columns := make([]string, 0, len(table.PrimaryKeys)+2)
+	for _, key := range list.Table.SimpleFields[:len(table.PrimaryKeys)] {
+		keyConditions = append(keyConditions, writer.dialect.QuoteIdentifier(key.Name)+" = ?")
+		columns = append(columns, key.Name)
+	}
+	where := " WHERE " + strings.Join(keyConditions, " AND ")
+	args := "value"
+	order := ""
+	if list.Ordered {
+		ordinal := list.Table.SimpleFields[len(table.PrimaryKeys)].Name
+		columns = append(columns, ordinal)
+		args = "i, value"
+		order = " ORDER BY " + writer.dialect.QuoteIdentifier(ordinal)
+	}
+	columns = append(columns, list.Value.Name)
+	valueType := writer.typeName(list.Value)
+	codec := writer.codec(list.Value)
+	if codec.encode != "" {
+		args = strings.Replace(args, "value", codec.encode+"(value)", 1)
+	}
+
+	writer.printf("\nfunc (repo *%sRepository) save%s(ctx context.Context, values []%s, keys ...interface{}) error {\n", table.Type.Name(), list.Name, valueType)
+	writer.printf("\tif _, err := repo.DB.ExecContext(ctx, %s, keys...); err != nil {\n\t\treturn err\n\t}\n", writer.query("DELETE FROM "+auxName+where))
+	if list.Ordered {
+		writer.printf("\tfor i, value := range values {\n")
+	} else {
+		writer.printf("\tfor _, value := range values {\n")
+	}
+	outputGoEnumCheck(table.Type.Name()+"."+list.Name, "value", list.Value, writer)
+	writer.printf("\t\targs := append(keys[:len(keys):len(keys)], %s)\n", args)
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", auxName, quoteIdentifiers(writer.dialect, columns), goPlaceholders(len(columns)))
+	writer.printf("\t\tif _, err := repo.DB.ExecContext(ctx, %s, args...); err != nil {\n\t\t\treturn err\n\t\t}\n\t}\n", writer.query(query))
+	writer.printf("\treturn nil\n}\n")
+
+	writer.printf("\nfunc (repo *%sRepository) load%s(ctx context.Context, keys ...interface{}) ([]%s, error) {\n", table.Type.Name(), list.Name, valueType)
+	query = "SELECT " + writer.dialect.QuoteIdentifier(list.Value.Name) + " FROM " + auxName + where + order
+	writer.printf("\trows, err := repo.DB.QueryContext(ctx, %s, keys...)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\tdefer rows.Close()\n", writer.query(query))
+	if codec.decode != "" {
+		writer.printf("\tvalues := []%s{}\n\tfor rows.Next() {\n\t\tvar value %s\n", valueType, codec.scanType)
+		writer.printf("\t\tif err := rows.Scan(&value); err != nil {\n\t\t\treturn nil, err\n\t\t}\n")
+		writer.printf("%s\t}\n", codec.decodeStatements("values = append(values, %s)", "value", "\t\t", "nil, err"))
+	} else {
+		target := "&value"
+		if codec.scanner != "" {
+			target = codec.scanner + "(&value)"
+		}
+		writer.printf("\tvalues := []%s{}\n\tfor rows.Next() {\n\t\tvar value %s\n", valueType, valueType)
+		writer.printf("\t\tif err := rows.Scan(%s); err != nil {\n\t\t\treturn nil, err\n\t\t}\n\t\tvalues = append(values, value)\n\t}\n", target)
+	}
+	writer.printf("\treturn values, rows.Err()\n}\n")
+}
+
+// outputGoEnumChecks writes the statements returning an error if an enum column of the model has a value that is not allowed.
+func outputGoEnumChecks(name string, columns []*goColumn, writer *goWriter) {
+	for _, column := range columns {
+		if column.backed() && column.edge == "" {
+			outputGoEnumCheck(name+"."+column.selector, "entity."+column.selector, column.MysqlField, writer)
+		}
+	}
+}
+
+// outputGoEnumCheck writes the statements returning an error if expr, which is the value of field, is not allowed by field.Values.
+func outputGoEnumCheck(label string, expr string, field *MysqlField, writer *goWriter) {
+	if len(field.Values) == 0 {
+		return
+	}
+	closing := ""
+	if isSetType(field.Type) {
+		writer.printf("for _, value := range %s {\n", expr)
+		expr = "value"
+		closing += "}\n"
+	}
+	if field.Nullable {
+		writer.printf("if %s != nil {\n", expr)
+		expr = "*" + expr
+		closing += "}\n"
+	}
+	writer.imports["fmt"] = true
+	if field.GoOrdinal {
+		// negative values are converted to large numbers
+		writer.printf("if uint64(%s) >= %d {\nreturn fmt.Errorf(\"invalid %s %%d\", %s)\n}\n", expr, len(field.Values), label, expr)
+		writer.printf("%s", closing)
+		return
+	}
+	quoted := make([]string, 0, len(field.Values))
+	for _, value := range field.Values {
+		quoted = append(quoted, strconv.Quote(value))
+	}
+	writer.printf("switch %s {\ncase %s:\ndefault:\nreturn fmt.Errorf(\"invalid %s %%q\", %s)\n}\n", expr, strings.Join(quoted, ", "), label, expr)
+	writer.printf("%s", closing)
+}
+
+// outputGoEdgeValues declares the locals holding the values of columns referencing other models, which are NULL if the edge is nil.
+func outputGoEdgeValues(columns []*goColumn, writer *goWriter) {
+	for _, column := range columns {
+		if column.edge != "" {
+			writer.printf("\tvar %s interface{}\n\tif entity.%s != nil {\n\t\t%s = %s\n\t}\n", column.local, column.edge, column.local, column.encoded("entity."+column.selector))
+		}
+	}
+}
+
+func goColumnNames(columns []*goColumn, separator string, dialect Dialect) string {
+	names := make([]string, 0, len(columns))
+	for _, column := range columns {
+		names = append(names, dialect.QuoteIdentifier(column.Name))
+	}
+	return strings.Join(names, separator)
+}
+
+func goColumnValues(columns []*goColumn) string {
+	values := ""
+	for _, column := range columns {
+		values += ", " + column.value()
+	}
+	return values
+}
+
+func goPlaceholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+// goEqualities returns the condition that each of the left expressions equals the right expression at the same index.
+func goEqualities(left []string, right []string) string {
+	conditions := make([]string, 0, len(left))
+	for i := range left {
+		conditions = append(conditions, left[i]+" = "+right[i])
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+func goPrefixed(prefix string, names []string) []string {
+	prefixed := make([]string, 0, len(names))
+	for _, name := range names {
+		prefixed = append(prefixed, prefix+name)
+	}
+	return prefixed
+}
+
+// goLocalName converts a column name such as "Author_Id" or "author_id" into a Go identifier such as "authorId".
+func goLocalName(column string) string {
+	name := ""
+	for _, piece := range strings.FieldsFunc(column, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	}) {
+		if name == "" {
+			name = strings.ToLower(piece[:1]) + piece[1:]
+		} else {
+			name += strings.ToUpper(piece[:1]) + piece[1:]
+		}
+	}
+	switch {
+	case name == "", !unicode.IsLetter([]rune(name)[0]):
+		name = "column" + name
+	case token.IsKeyword(name), name == "ctx", name == "entity", name == "repo", name == "result", name == "err", name == "insertId",
+		name == "columns", name == "values":
+		name += "_"
+	}
+	return name
+}
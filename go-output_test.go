@@ -0,0 +1,501 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// repositoryModels exercises most kinds of fields that the generated repositories read and write.
+const repositoryModels = `package models
+
+import "time"
+
+type User struct {
+	_        struct{}   ` + "`comment:\"registered users\"`" + `
+	Id       uint32     ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	// Name is the login name.
+	Name     string     ` + "`width:\"64\" unique:\"name\"`" + `
+	Email    *string    ` + "`width:\"128\"`" + `
+	Created  *time.Time ` + "`default:\"CURRENT_TIMESTAMP\"`" + `
+	Updated  *time.Time ` + "`onUpdate:\"CURRENT_TIMESTAMP\"`" + `
+	Level    Priority   ` + "`enum:\"low, mid, high\"`" + `
+	Levels   []Priority ` + "`enum:\"low,mid,high\"`" + `
+	Status   Status     ` + "`enum:\"\" default:\"active\"`" + `
+	Roles    []Status   ` + "`enum:\"\"`" + `
+	Tags     []string   ` + "`width:\"32\" ordered:\"\"`" + `
+	Posts    []Post     ` + "`ordered:\"\"`" + `
+	Profile  Profile
+	Favorite *Post
+	Manager  *User
+}
+
+type Priority uint8
+
+type Status string
+
+const (
+	StatusActive Status = "active"
+	StatusBanned Status = "banned"
+)
+
+type Profile struct {
+	Id  uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Bio string ` + "`text:\"\"`" + `
+}
+
+type Post struct {
+	Id     uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Title  string ` + "`width:\"128\"`" + `
+	Editor *User  ` + "`nullable:\"\" onDelete:\"setnull\"`" + `
+}
+`
+
+var sourceImporter = importer.ForCompiler(token.NewFileSet(), "source", nil)
+
+// sourceModels type-checks the source of a models package and returns the types with the given names.
+func sourceModels(t *testing.T, source string, names ...string) (*token.FileSet, *ast.File, []TypeInfo) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "models.go", source, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parse models: %v", err)
+	}
+	config := &types.Config{Importer: sourceImporter}
+	pkg, err := config.Check("example.com/models", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("type-check models: %v", err)
+	}
+	sourcePackage := NewSourcePackage(fset, file)
+	models := make([]TypeInfo, 0, len(names))
+	for _, name := range names {
+		object := pkg.Scope().Lookup(name)
+		if object == nil {
+			t.Fatalf("type %s is not declared in the models", name)
+		}
+		models = append(models, sourcePackage.Type(object.Type()))
+	}
+	return fset, file, models
+}
+
+// checkGeneratedGo type-checks the generated repositories together with the models they were generated for.
+func checkGeneratedGo(t *testing.T, fset *token.FileSet, models *ast.File, generated string) {
+	t.Helper()
+	file, err := parser.ParseFile(fset, "mymodel_gen.go", generated, 0)
+	if err != nil {
+		t.Fatalf("parse generated code: %v\n%s", err, generated)
+	}
+	config := &types.Config{Importer: sourceImporter}
+	if _, err := config.Check("example.com/models", fset, []*ast.File{models, file}, nil); err != nil {
+		t.Fatalf("type-check generated code: %v", err)
+	}
+}
+
+func TestOutputGoCompiles(t *testing.T) {
+	fset, file, models := sourceModels(t, repositoryModels, "User")
+	for name, dialect := range map[string]Dialect{"mysql": MysqlDialect{}, "postgres": PostgresDialect{}, "sqlite": SqliteDialect{}} {
+		t.Run(name, func(t *testing.T) {
+			sql, generated := &strings.Builder{}, &strings.Builder{}
+			config := GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", SqlStream: sql, GoStream: generated, Dialect: dialect}
+			if err := GenerateTypes(config, models); err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+			checkGeneratedGo(t, fset, file, generated.String())
+		})
+	}
+}
+
+func TestOrderedChildren(t *testing.T) {
+	_, _, models := sourceModels(t, repositoryModels, "User")
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	post := schema.Tables["Post"]
+	if field := post.FindField("Posts_Position"); field == nil || field.Nullable {
+		t.Errorf("expected the NOT NULL position column Posts_Position, got %+v", field)
+	}
+	if columns := post.UniqueKeys["Posts_Position"]; !stringSlicesEqual(columns, []string{"Parent___Id", "Posts_Position"}) {
+		t.Errorf("expected the positions to be unique per parent, got %v", columns)
+	}
+
+	generated := &strings.Builder{}
+	if err := schema.OutputGo(GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", GoStream: generated}); err != nil {
+		t.Fatalf("output Go: %v", err)
+	}
+	code := generated.String()
+	for method, call := range map[string]string{"Insert": "repo.savePosts(ctx, entity)", "Update": "repo.savePosts(ctx, entity)", "GetByPrimaryKey": "repo.loadPosts(ctx, entity)"} {
+		start := strings.Index(code, "func (repo *UserRepository) "+method+"(")
+		if start < 0 {
+			t.Errorf("expected UserRepository.%s to be generated", method)
+			continue
+		}
+		body := code[start:]
+		body = body[:strings.Index(body, "\n}\n")]
+		if !strings.Contains(body, call) {
+			t.Errorf("expected UserRepository.%s to call %s, got\n%s", method, call, body)
+		}
+	}
+	if !strings.Contains(code, "children.Insert(ctx, &entity.Posts[i], entity.Id, i)") {
+		t.Errorf("expected savePosts to insert the posts with their positions, got\n%s", code)
+	}
+	if strings.Contains(code, "DELETE FROM `Post` WHERE `Parent___Id` = ?") {
+		t.Errorf("expected savePosts to keep the stored posts, got\n%s", code)
+	}
+}
+
+// recordingDriver is compiled with the generated repositories to run them without a database.
+// It records the statements executed, and answers each query with the rows scripted for it.
+const recordingDriver = `package models
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type recordingConn struct {
+	statements []string
+	rows       map[string][][]driver.Value // the rows returned by each query
+	lastId     int64
+}
+
+func (conn *recordingConn) Connect(context.Context) (driver.Conn, error) { return conn, nil }
+func (conn *recordingConn) Driver() driver.Driver                        { return nil }
+func (conn *recordingConn) Prepare(string) (driver.Stmt, error)          { return nil, errors.New("unexpected prepare") }
+func (conn *recordingConn) Close() error                                 { return nil }
+func (conn *recordingConn) Begin() (driver.Tx, error)                    { return nil, errors.New("unexpected transaction") }
+
+func (conn *recordingConn) record(query string, args []driver.NamedValue) {
+	values := make([]string, 0, len(args))
+	for _, arg := range args {
+		values = append(values, fmt.Sprint(arg.Value))
+	}
+	conn.statements = append(conn.statements, query+" ["+strings.Join(values, ", ")+"]")
+}
+
+func (conn *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	conn.record(query, args)
+	if strings.HasPrefix(query, "INSERT") {
+		conn.lastId++
+	}
+	return recordedResult(conn.lastId), nil
+}
+
+func (conn *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	conn.record(query, args)
+	rows, exists := conn.rows[query]
+	if !exists {
+		return nil, fmt.Errorf("unexpected query %s", query)
+	}
+	return &recordedRows{rows: rows}, nil
+}
+
+type recordedResult int64
+
+func (result recordedResult) LastInsertId() (int64, error) { return int64(result), nil }
+func (result recordedResult) RowsAffected() (int64, error) { return 1, nil }
+
+type recordedRows struct {
+	rows [][]driver.Value
+}
+
+func (rows *recordedRows) Columns() []string {
+	if len(rows.rows) == 0 {
+		return []string{"column"}
+	}
+	return make([]string, len(rows.rows[0]))
+}
+func (rows *recordedRows) Close() error { return nil }
+func (rows *recordedRows) Next(dest []driver.Value) error {
+	if len(rows.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, rows.rows[0])
+	rows.rows = rows.rows[1:]
+	return nil
+}
+`
+
+// runGeneratedTest runs the tests in files against the generated repositories, which are compiled with the models and recordingDriver.
+func runGeneratedTest(t *testing.T, models string, generated string, files map[string]string) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("the generated repositories are compiled by the go command")
+	}
+	goCommand, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go command is not available")
+	}
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/models\n\ngo 1.18\n"
+	files["models.go"] = models
+	files["mymodel_gen.go"] = generated
+	files["driver_test.go"] = recordingDriver
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	command := exec.Command(goCommand, "test", ".")
+	command.Dir = dir
+	command.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+	if output, err := command.CombinedOutput(); err != nil {
+		t.Fatalf("test the generated repositories: %v\n%s", err, output)
+	}
+}
+
+const threadModels = `package models
+
+type Thread struct {
+	Id    uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Title string ` + "`width:\"64\"`" + `
+	Posts []Post ` + "`ordered:\"\"`" + `
+}
+
+type Post struct {
+	Id   uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Body string ` + "`text:\"\"`" + `
+}
+`
+
+// threadUpdateTest updates a thread whose stored posts are 1, 2 and 3 to contain the posts 3, a new one and 1.
+const threadUpdateTest = `package models
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUpdateKeepsPosts(t *testing.T) {
+	conn := &recordingConn{
+		rows:   map[string][][]driver.Value{"SELECT ` + "`Id`" + ` FROM ` + "`Post`" + ` WHERE ` + "`Parent___Id`" + ` = ?": {{int64(1)}, {int64(2)}, {int64(3)}}},
+		lastId: 3,
+	}
+	repo := &ThreadRepository{DB: sql.OpenDB(conn)}
+	thread := &Thread{Id: 7, Title: "thread", Posts: []Post{{Id: 3, Body: "third"}, {Body: "new"}, {Id: 1, Body: "first"}}}
+	if err := repo.Update(context.Background(), thread); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	ids := []uint32{}
+	for _, post := range thread.Posts {
+		ids = append(ids, post.Id)
+	}
+	if !reflect.DeepEqual(ids, []uint32{3, 4, 1}) {
+		t.Errorf("expected the posts 3 and 1 to keep their ids and the new post to be 4, got %v", ids)
+	}
+	inserts, deletes := []string{}, []string{}
+	for _, statement := range conn.statements {
+		if strings.HasPrefix(statement, "INSERT") {
+			inserts = append(inserts, statement)
+		} else if strings.HasPrefix(statement, "DELETE") {
+			deletes = append(deletes, statement)
+		}
+	}
+	if len(inserts) != 1 || !strings.HasSuffix(inserts[0], "[new, 7, 1]") {
+		t.Errorf("expected only the new post to be inserted at position 1, got %v", inserts)
+	}
+	if !reflect.DeepEqual(deletes, []string{"DELETE FROM ` + "`Post`" + ` WHERE ` + "`Id`" + ` = ? [2]"}) {
+		t.Errorf("expected only post 2 to be deleted, got %v", deletes)
+	}
+	for _, expected := range []string{"UPDATE ` + "`Post`" + ` SET ` + "`Posts_Position`" + ` = ? WHERE ` + "`Id`" + ` = ? [0, 3]", "UPDATE ` + "`Post`" + ` SET ` + "`Posts_Position`" + ` = ? WHERE ` + "`Id`" + ` = ? [2, 1]"} {
+		found := false
+		for _, statement := range conn.statements {
+			found = found || statement == expected
+		}
+		if !found {
+			t.Errorf("expected %s, got %v", expected, conn.statements)
+		}
+	}
+}
+`
+
+func TestOrderedChildrenKeepKeys(t *testing.T) {
+	_, _, models := sourceModels(t, threadModels, "Thread")
+	generated := &strings.Builder{}
+	config := GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", SqlStream: &strings.Builder{}, GoStream: generated}
+	if err := GenerateTypes(config, models); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	runGeneratedTest(t, threadModels, generated.String(), map[string]string{"update_test.go": threadUpdateTest})
+}
+
+const albumModels = `package models
+
+type Album struct {
+	Id     uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Cover  Cover
+	Photos []Photo
+}
+
+type Cover struct {
+	Id  uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Url string ` + "`width:\"128\"`" + `
+}
+
+type Photo struct {
+	Id      uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Album   *Album ` + "`parent:\"\"`" + `
+	Caption string ` + "`width:\"64\"`" + `
+}
+`
+
+// albumTest inserts an album with a cover and two photos, then updates it after the first photo is removed.
+const albumTest = `package models
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func statementsLike(statements []string, prefix string) []string {
+	matched := []string{}
+	for _, statement := range statements {
+		if strings.HasPrefix(statement, prefix) {
+			matched = append(matched, statement)
+		}
+	}
+	return matched
+}
+
+func TestSaveChildren(t *testing.T) {
+	conn := &recordingConn{rows: map[string][][]driver.Value{
+		"SELECT ` + "`Id`" + ` FROM ` + "`Cover`" + ` WHERE ` + "`Album_Id`" + ` = ?": {},
+		"SELECT ` + "`Id`" + ` FROM ` + "`Photo`" + ` WHERE ` + "`Album_Id`" + ` = ?": {},
+	}}
+	repo := &AlbumRepository{DB: sql.OpenDB(conn)}
+	album := &Album{Cover: Cover{Url: "cover.png"}, Photos: []Photo{{Caption: "first"}, {Caption: "second"}}}
+	if err := repo.Insert(context.Background(), album); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if inserted := statementsLike(conn.statements, "INSERT INTO ` + "`Cover`" + `"); len(inserted) != 1 || !strings.HasSuffix(inserted[0], "[cover.png, 1]") {
+		t.Errorf("expected the cover to be inserted for album 1, got %v", inserted)
+	}
+	if inserted := statementsLike(conn.statements, "INSERT INTO ` + "`Photo`" + `"); len(inserted) != 2 || !strings.HasSuffix(inserted[0], "[first, 1]") {
+		t.Errorf("expected the photos to be inserted for album 1, got %v", inserted)
+	}
+	if album.Photos[0].Album != album || album.Cover.Id == 0 || album.Photos[1].Id == 0 {
+		t.Errorf("expected the children to be inserted with the album as parent, got %+v", album)
+	}
+
+	conn.statements = nil
+	conn.rows["SELECT ` + "`Id`" + ` FROM ` + "`Cover`" + ` WHERE ` + "`Album_Id`" + ` = ?"] = [][]driver.Value{{int64(album.Cover.Id)}}
+	conn.rows["SELECT ` + "`Id`" + ` FROM ` + "`Photo`" + ` WHERE ` + "`Album_Id`" + ` = ?"] = [][]driver.Value{{int64(album.Photos[0].Id)}, {int64(album.Photos[1].Id)}}
+	removed, kept := album.Photos[0].Id, album.Photos[1].Id
+	album.Photos = album.Photos[1:]
+	if err := repo.Update(context.Background(), album); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if inserted := statementsLike(conn.statements, "INSERT"); len(inserted) != 0 {
+		t.Errorf("expected the stored children to be updated, got %v", inserted)
+	}
+	if deleted := statementsLike(conn.statements, "DELETE"); !reflect.DeepEqual(deleted, []string{"DELETE FROM ` + "`Photo`" + ` WHERE ` + "`Id`" + ` = ? [" + fmt.Sprint(removed) + "]"}) {
+		t.Errorf("expected only the removed photo to be deleted, got %v", deleted)
+	}
+	if len(statementsLike(conn.statements, "UPDATE ` + "`Cover`" + `")) != 1 || len(statementsLike(conn.statements, "UPDATE ` + "`Photo`" + `")) != 1 || album.Photos[0].Id != kept {
+		t.Errorf("expected the cover and the kept photo to be updated, got %v", conn.statements)
+	}
+}
+`
+
+func TestContainedChildren(t *testing.T) {
+	_, _, models := sourceModels(t, albumModels, "Album")
+	generated := &strings.Builder{}
+	config := GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", SqlStream: &strings.Builder{}, GoStream: generated}
+	if err := GenerateTypes(config, models); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	code := generated.String()
+	start := strings.Index(code, "func (repo *AlbumRepository) GetByPrimaryKey(")
+	if body := code[start : start+strings.Index(code[start:], "\n}\n")]; !strings.Contains(body, "repo.loadCover(ctx, entity)") || !strings.Contains(body, "repo.loadPhotos(ctx, entity)") {
+		t.Errorf("expected GetByPrimaryKey to load the cover and the photos, got\n%s", body)
+	}
+	runGeneratedTest(t, albumModels, code, map[string]string{"album_test.go": albumTest})
+}
+
+func TestContainedChildrenWithoutKeys(t *testing.T) {
+	const source = `package models
+
+type Album struct {
+	Id    uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Cover Cover
+}
+
+type Cover struct {
+	Url string ` + "`width:\"128\"`" + `
+}
+`
+	fset, file, models := sourceModels(t, source, "Album")
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	generated := &strings.Builder{}
+	if err := schema.OutputGo(GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", GoStream: generated}); err != nil {
+		t.Fatalf("output go: %v", err)
+	}
+	diagnostics := schema.Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Severity != SeverityWarning || diagnostics[0].Type != "Album" || diagnostics[0].Field != "Cover" || diagnostics[0].Position == "" {
+		t.Errorf("expected a warning about Album.Cover, got %v", diagnostics)
+	}
+	if strings.Contains(generated.String(), "saveCover") {
+		t.Errorf("expected the repository of Album to skip Cover, got\n%s", generated)
+	}
+	checkGeneratedGo(t, fset, file, generated.String())
+}
+
+// TestGenerateWritesNothingOnFailure checks that the SQL and Go code are not written if a later output fails.
+func TestGenerateWritesNothingOnFailure(t *testing.T) {
+	_, _, models := sourceModels(t, albumModels, "Album")
+	previous, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	sqlStream, goStream := &strings.Builder{}, &strings.Builder{}
+	config := GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", SqlStream: sqlStream, GoStream: goStream,
+		Previous: previous, MigrationStream: &strings.Builder{}, Dialect: PostgresDialect{}}
+	if err := GenerateTypes(config, models); err == nil {
+		t.Fatalf("expected the migration to fail for Postgres")
+	}
+	if sqlStream.Len() != 0 || goStream.Len() != 0 {
+		t.Errorf("expected nothing to be written, got %d bytes of SQL and %d bytes of Go", sqlStream.Len(), goStream.Len())
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxIdentifierLength is the maximum length of the names of tables, columns, indexes and constraints in MySQL.
+const maxIdentifierLength = 64
+
+// mysqlReservedWords are the keywords that MySQL 8.0 only accepts as identifiers if they are quoted.
+var mysqlReservedWords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`
+		ACCESSIBLE ADD ALL ALTER ANALYZE AND AS ASC ASENSITIVE BEFORE BETWEEN BIGINT BINARY BLOB BOTH BY
+		CALL CASCADE CASE CHANGE CHAR CHARACTER CHECK COLLATE COLUMN CONDITION CONSTRAINT CONTINUE CONVERT
+		CREATE CROSS CUBE CUME_DIST CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP CURRENT_USER CURSOR
+		DATABASE DATABASES DAY_HOUR DAY_MICROSECOND DAY_MINUTE DAY_SECOND DEC DECIMAL DECLARE DEFAULT
+		DELAYED DELETE DENSE_RANK DESC DESCRIBE DETERMINISTIC DISTINCT DISTINCTROW DIV DOUBLE DROP DUAL
+		EACH ELSE ELSEIF EMPTY ENCLOSED ESCAPED EXCEPT EXISTS EXIT EXPLAIN FALSE FETCH FIRST_VALUE FLOAT
+		FLOAT4 FLOAT8 FOR FORCE FOREIGN FROM FULLTEXT FUNCTION GENERATED GET GRANT GROUP GROUPING GROUPS
+		HAVING HIGH_PRIORITY HOUR_MICROSECOND HOUR_MINUTE HOUR_SECOND IF IGNORE IN INDEX INFILE INNER INOUT
+		INSENSITIVE INSERT INT INT1 INT2 INT3 INT4 INT8 INTEGER INTERSECT INTERVAL INTO IO_AFTER_GTIDS
+		IO_BEFORE_GTIDS IS ITERATE JOIN JSON_TABLE KEY KEYS KILL LAG LAST_VALUE LATERAL LEAD LEADING LEAVE
+		LEFT LIKE LIMIT LINEAR LINES LOAD LOCALTIME LOCALTIMESTAMP LOCK LONG LONGBLOB LONGTEXT LOOP
+		LOW_PRIORITY MASTER_BIND MASTER_SSL_VERIFY_SERVER_CERT MATCH MAXVALUE MEDIUMBLOB MEDIUMINT MEDIUMTEXT
+		MIDDLEINT MINUTE_MICROSECOND MINUTE_SECOND MOD MODIFIES NATURAL NOT NO_WRITE_TO_BINLOG NTH_VALUE
+		NTILE NULL NUMERIC OF ON OPTIMIZE OPTIMIZER_COSTS OPTION OPTIONALLY OR ORDER OUT OUTER OUTFILE OVER
+		PARTITION PERCENT_RANK PRECISION PRIMARY PROCEDURE PURGE RANGE RANK READ READS READ_WRITE REAL
+		RECURSIVE REFERENCES REGEXP RELEASE RENAME REPEAT REPLACE REQUIRE RESIGNAL RESTRICT RETURN REVOKE
+		RIGHT RLIKE ROW ROWS ROW_NUMBER SCHEMA SCHEMAS SECOND_MICROSECOND SELECT SENSITIVE SEPARATOR SET
+		SHOW SIGNAL SMALLINT SPATIAL SPECIFIC SQL SQLEXCEPTION SQLSTATE SQLWARNING SQL_BIG_RESULT
+		SQL_CALC_FOUND_ROWS SQL_SMALL_RESULT SSL STARTING STORED STRAIGHT_JOIN SYSTEM TABLE TERMINATED THEN
+		TINYBLOB TINYINT TINYTEXT TO TRAILING TRIGGER TRUE UNDO UNION UNIQUE UNLOCK UNSIGNED UPDATE USAGE
+		USE USING UTC_DATE UTC_TIME UTC_TIMESTAMP VALUES VARBINARY VARCHAR VARCHARACTER VARYING VIRTUAL WHEN
+		WHERE WHILE WINDOW WITH WRITE XOR YEAR_MONTH ZEROFILL`) {
+		mysqlReservedWords[word] = true
+	}
+}
+
+// checkIdentifiers reports the names of tables, columns, indexes and foreign keys that are too long for MySQL,
+// and warns about names that are reserved words, which are quoted in the generated code but not in handwritten queries.
+// It also reports columns whose names only differ in case from another column of the table, such as a field named after a foreign key column,
+// since column names are case-insensitive.
+func (schema *Schema) checkIdentifiers() {
+	names := make([]string, 0, len(schema.Tables))
+	for name := range schema.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		table := schema.Tables[name]
+		for _, checked := range append([]*Table{table.Table}, table.AuxTables...) {
+			derived := checked != table.Table
+			schema.checkIdentifier(table, "", "table "+checked.Name, checked.Name, derived)
+			columns := map[string]bool{}
+			for _, field := range checked.SimpleFields {
+				if columns[strings.ToLower(field.Name)] {
+					schema.reportField(SeverityError, table, field.GoName, "", "column %s.%s is declared more than once; rename one of the fields with the column tag", checked.Name, field.Name)
+				}
+				columns[strings.ToLower(field.Name)] = true
+				if derived || field.GoName == "" {
+					schema.checkIdentifier(table, "", "column "+checked.Name+"."+field.Name, field.Name, true)
+				} else {
+					schema.checkIdentifier(table, field.GoName, "column "+field.Name, field.Name, false)
+				}
+			}
+			for _, keys := range []map[string][]string{checked.UniqueKeys, checked.CompositeKeys} {
+				for _, indexName := range sortedKeyNames(keys) {
+					schema.checkIdentifier(table, "", "index "+indexName, indexName, false)
+				}
+			}
+			for _, foreign := range checked.ForeignKeys {
+				foreignName := checked.ForeignKeyName(foreign)
+				schema.checkIdentifier(table, "", "foreign key "+foreignName, foreignName, true)
+			}
+		}
+	}
+}
+
+// checkIdentifier reports name if it cannot be used as is, where description names the object called name in messages,
+// and derived indicates that the name is built from the names of models, fields and keys.
+func (schema *Schema) checkIdentifier(table *MainTable, field string, description string, name string, derived bool) {
+	if len(name) > maxIdentifierLength {
+		hint := ""
+		if derived {
+			hint = "; it is derived from the names of models, fields and keys, so one of them must be shortened"
+		}
+		schema.reportField(SeverityError, table, field, "", "%s is longer than %d characters%s", description, maxIdentifierLength, hint)
+	} else if mysqlReservedWords[strings.ToUpper(name)] {
+		schema.reportField(SeverityWarning, table, field, "", "%s is a reserved word in MySQL, so it must be quoted in handwritten queries", description)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReservedWords(t *testing.T) {
+	_, _, models := sourceModels(t, "package models\n\ntype Order struct {\n\tKey uint32 `primaryKey:\"\"`\n}\n", "Order")
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("expected reserved words to be accepted, got %v", err)
+	}
+	expected := "Order: warning: table Order is a reserved word in MySQL, so it must be quoted in handwritten queries\n" +
+		"models.go:4:2: Order.Key: warning: column Key is a reserved word in MySQL, so it must be quoted in handwritten queries"
+	if diagnostics := schema.Diagnostics().Error(); diagnostics != expected {
+		t.Errorf("expected warnings about the reserved words\n%s\ngot\n%s", expected, diagnostics)
+	}
+	if sql := outputSql(t, models, MysqlDialect{}); !strings.HasPrefix(sql, "CREATE TABLE `Order` (\n\t`Key` INT UNSIGNED NOT NULL,") {
+		t.Errorf("expected the reserved words to be quoted, got\n%s", sql)
+	}
+}
+
+func TestInvalidIdentifiers(t *testing.T) {
+	for name, source := range map[string]string{
+		"too long":  "package models\n\ntype Row struct {\n\tId uint32 `primaryKey:\"\" column:\"" + strings.Repeat("x", maxIdentifierLength+1) + "\"`\n}\n",
+		"same case": "package models\n\ntype Row struct {\n\tId uint32 `primaryKey:\"\"`\n\tName uint32\n\tNAME uint32\n}\n",
+	} {
+		_, _, models := sourceModels(t, source, "Row")
+		if _, err := BuildSchemaFromTypes(models); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
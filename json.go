@@ -0,0 +1,106 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Fields with the jsonColumn tag are stored as JSON documents, which are marshaled and unmarshaled by the generated code.
+// The tag is not called json, since models commonly declare the json tags of encoding/json to name their fields in documents,
+// which would turn every such field into a JSON column; the jsonColumn tag does not affect encoding/json.
+// Maps and interfaces can only be stored as JSON documents, so they must declare the tag as well.
+
+var (
+	jsonPathPattern      = regexp.MustCompile(`^\$(\.[A-Za-z_][A-Za-z0-9_]*|\[[0-9]+\])+$`)
+	jsonPathElement      = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*|[0-9]+`)
+	jsonIndexNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+)
+
+// jsonIndexFields returns the generated columns declared by the jsonIndex tag of the JSON column named column.
+//
+// The tag is a list of "name path type" separated by semicolons, such as "email $.email VARCHAR(128); age $.age INT UNSIGNED".
+// Each path is stored in a generated column named column_name, which is indexed by an index of the same name.
+func jsonIndexFields(column string, tag string) ([]*MysqlField, error) {
+	fields := []*MysqlField{}
+	for _, declaration := range strings.Split(tag, ";") {
+		parts := strings.Fields(declaration)
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("jsonIndex %q must have a name, a path and a type", strings.TrimSpace(declaration))
+		}
+		if !jsonIndexNamePattern.MatchString(parts[0]) {
+			return nil, fmt.Errorf("jsonIndex name %q must be alphanumeric", parts[0])
+		}
+		if !jsonPathPattern.MatchString(parts[1]) {
+			return nil, fmt.Errorf("jsonIndex path %q must consist of member names and array indices", parts[1])
+		}
+		mysqlType, err := jsonIndexType(strings.Join(parts[2:], " "))
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, &MysqlField{
+			Name:     column + "_" + parts[0],
+			Type:     mysqlType,
+			Nullable: true, // the path may be absent
+			JsonPath: &JsonPath{Column: column, Path: parts[1]},
+		})
+	}
+	return fields, nil
+}
+
+// jsonIndexType returns the type of a generated column declared in SQL, which must be a type that can be indexed
+// and computed from a JSON value in every dialect.
+func jsonIndexType(declaration string) (string, error) {
+	mysqlType, err := parseMysqlType(declaration)
+	if err != nil {
+		return "", err
+	}
+	if !jsonScalarType(mysqlType) && !strings.HasPrefix(mysqlType, "VARCHAR(") && !strings.HasPrefix(mysqlType, "CHAR(") {
+		return "", errors.New("jsonIndex types must be numbers, booleans, VARCHAR or CHAR")
+	}
+	return mysqlType, nil
+}
+
+// jsonScalarType reports whether values of mysqlType are stored in JSON documents as numbers or booleans rather than strings.
+func jsonScalarType(mysqlType string) bool {
+	return mysqlType == "BOOL" || mysqlType == "FLOAT" || mysqlType == "DOUBLE" ||
+		strings.HasSuffix(mysqlType, "INT SIGNED") || strings.HasSuffix(mysqlType, "INT UNSIGNED") ||
+		strings.HasPrefix(mysqlType, "DECIMAL(")
+}
+
+// mysqlGeneratedClause returns the clause computing a generated column in MySQL, or an empty string for other columns.
+// Strings are unquoted, while other scalars are converted from their JSON value.
+func mysqlGeneratedClause(field *MysqlField) string {
+	if field.JsonPath == nil {
+		return ""
+	}
+	operator := "->>"
+	if jsonScalarType(field.Type) {
+		operator = "->"
+	}
+	return "GENERATED ALWAYS AS (" + MysqlDialect{}.QuoteIdentifier(field.JsonPath.Column) + operator + quoteSqlString(field.JsonPath.Path) + ") VIRTUAL"
+}
+
+// jsonPathElements returns the member names and array indices of a path, such as ["a", "b", "0"] for $.a.b[0].
+func jsonPathElements(path string) []string {
+	return jsonPathElement.FindAllString(strings.TrimPrefix(path, "$"), -1)
+}
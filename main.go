@@ -19,24 +19,145 @@
 package myModel
 
 import (
-	"encoding/json"
+	"bytes"
 	"errors"
-	"os"
+	"fmt"
+	"io"
 	"reflect"
 )
 
 func Generate(config GeneratorConfig, seeds []reflect.Type) error {
-	config.WriteGo("package " + config.Package + "\n")
+	models, err := seedTypes(seeds)
+	if err != nil {
+		return err
+	}
+	return GenerateTypes(config, models)
+}
+
+// GenerateTypes is like Generate, but takes the model struct types directly, for example from SourceType.
+func GenerateTypes(config GeneratorConfig, models []TypeInfo) error {
+	if err := config.TableOptions.check(); err != nil {
+		return fmt.Errorf("table options: %v", err)
+	}
+	schema, err := buildSchema(models, config.Types, config.Naming)
+	if err != nil {
+		return err
+	}
+	if err := config.writeDiagnostics(schema.Diagnostics()); err != nil {
+		return err
+	}
+
+	// nothing is written to the streams until every output is generated, so that a failure leaves all of them unchanged
+	streams := &bufferedStreams{}
+	streams.buffer(&config.SqlStream)
+	streams.buffer(&config.GoStream)
+	streams.buffer(&config.MigrationStream)
+	streams.buffer(&config.SnapshotStream)
+
+	if err := schema.OutputSql(config); err != nil {
+		return err
+	}
+	reported := len(schema.Diagnostics())
+	if err := schema.OutputGo(config); err != nil {
+		return err
+	}
+	if err := config.writeDiagnostics(schema.Diagnostics()[reported:]); err != nil {
+		return err
+	}
+	if config.Previous != nil && config.MigrationStream != nil {
+		migrationConfig := config
+		migrationConfig.SqlStream = config.MigrationStream
+		reported := len(schema.Diagnostics())
+		if err := schema.OutputMigration(config.Previous, migrationConfig); err != nil {
+			return err
+		}
+		if err := config.writeDiagnostics(schema.Diagnostics()[reported:]); err != nil {
+			return err
+		}
+	}
+
+	if config.SnapshotStream != nil {
+		if err := schema.Save(config.SnapshotStream); err != nil {
+			return err
+		}
+	}
+
+	return streams.flush()
+}
+
+// bufferedStreams holds the outputs of a generation until all of them are generated.
+type bufferedStreams struct {
+	streams []io.Writer
+	buffers []*bytes.Buffer
+}
+
+// buffer replaces the stream, if it is not nil, with a buffer that is written to the stream by flush.
+func (streams *bufferedStreams) buffer(stream *io.Writer) {
+	if *stream == nil {
+		return
+	}
+	buffer := &bytes.Buffer{}
+	streams.streams = append(streams.streams, *stream)
+	streams.buffers = append(streams.buffers, buffer)
+	*stream = buffer
+}
 
+// flush writes the buffered outputs to their streams.
+func (streams *bufferedStreams) flush() error {
+	for i, stream := range streams.streams {
+		if _, err := streams.buffers[i].WriteTo(stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDiagnostics writes diagnostics to config.DiagnosticStream, if it is not nil.
+func (config *GeneratorConfig) writeDiagnostics(diagnostics Diagnostics) error {
+	if config.DiagnosticStream == nil {
+		return nil
+	}
+	for _, diagnostic := range diagnostics {
+		if _, err := fmt.Fprintln(config.DiagnosticStream, diagnostic.Error()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildSchema computes the tables for the seeds and all types reachable from them.
+func BuildSchema(seeds []reflect.Type) (*Schema, error) {
+	models, err := seedTypes(seeds)
+	if err != nil {
+		return nil, err
+	}
+	return BuildSchemaFromTypes(models)
+}
+
+// BuildSchemaFromTypes is like BuildSchema, but takes the model struct types directly.
+//
+// If there are problems in the models, all of them are returned as Diagnostics.
+// Warnings alone do not fail the build, and are available from Schema.Diagnostics.
+func BuildSchemaFromTypes(models []TypeInfo) (*Schema, error) {
+	return buildSchema(models, nil, nil)
+}
+
+// buildSchema is like BuildSchemaFromTypes, with the type mappings of GeneratorConfig.Types and the names of GeneratorConfig.Naming.
+func buildSchema(models []TypeInfo, types *TypeRegistry, naming NamingStrategy) (*Schema, error) {
+	if naming == nil {
+		naming = DefaultNaming{}
+	}
 	schema := &Schema{
 		Tables: map[string]*MainTable{},
+		types:  types,
+		naming: naming,
 	}
 
-	for _, seed := range seeds {
-		if seed.Kind() != reflect.Ptr || seed.Elem().Kind() != reflect.Struct {
-			return errors.New("seeds must be pointers to structs")
+	for _, model := range models {
+		if model.Kind() != reflect.Struct {
+			return nil, errors.New("models must be structs")
 		}
-		schema.getTable(seed.Elem())
+		schema.getTable(model)
 	}
 
 	incomplete := true
@@ -45,31 +166,29 @@ func Generate(config GeneratorConfig, seeds []reflect.Type) error {
 		for _, table := range schema.Tables {
 			if !table.yielded {
 				incomplete = true
-				if err := schema.yieldTable(table); err != nil {
-					return err
-				}
+				schema.yieldTable(table)
 			}
 		}
 	}
 
-	if err := schema.computeEdges(); err != nil {
-		return err
-	}
-
-	schema.OutputSql(config)
-
-	// file, err := os.Create("schema.json")
-	// if err != nil {
-	// 	return err
-	// }
-	// defer file.Close()
+	schema.pairEdges()
+	schema.deferCycles()
+	schema.computeEdges()
+	schema.checkIdentifiers()
 
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "\t")
-	err := encoder.Encode(schema)
-	if err != nil {
-		return err
+	if schema.diagnostics.HasErrors() {
+		return nil, schema.diagnostics
 	}
+	return schema, nil
+}
 
-	return nil
+func seedTypes(seeds []reflect.Type) ([]TypeInfo, error) {
+	models := make([]TypeInfo, 0, len(seeds))
+	for _, seed := range seeds {
+		if seed.Kind() != reflect.Ptr || seed.Elem().Kind() != reflect.Struct {
+			return nil, errors.New("seeds must be pointers to structs")
+		}
+		models = append(models, ReflectType(seed.Elem()))
+	}
+	return models, nil
 }
@@ -0,0 +1,289 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"errors"
+	"strings"
+)
+
+// OutputMigration writes the statements that migrate a database created from previous to this schema.
+//
+// Tables, columns and keys are matched by name, so a renamed column is dropped and added again.
+// The statements are ordered such that every foreign key only refers to tables and columns that exist:
+// obsolete foreign keys are dropped first, including those closing reference cycles between obsolete tables
+// and those whose columns change their types, which MySQL does not allow while the foreign key exists,
+// then obsolete tables (dependents first), then the remaining tables are altered and the new tables are created in dependency order,
+// and finally the new foreign keys of the altered tables and the foreign keys closing reference cycles in the new tables are added.
+//
+// Foreign keys added to existing tables on new NOT NULL columns are reported as warnings in the diagnostics of the schema,
+// because the rows already in the table cannot reference any row.
+//
+// Migrations are only generated in the MySQL dialect.
+func (schema *Schema) OutputMigration(previous *Schema, config GeneratorConfig) error {
+	if _, isMysql := config.dialect().(MysqlDialect); !isMysql {
+		return errors.New("migrations are only supported for the MySQL dialect")
+	}
+	dialect := MysqlDialect{}
+
+	oldTables := previous.getAllTables()
+	newTables := schema.getAllTables()
+	oldByName := tablesByName(oldTables)
+	newByName := tablesByName(newTables)
+
+	for _, oldTable := range oldTables {
+		newTable, exists := newByName[oldTable.Name]
+		clauses := []string{}
+		for _, foreign := range oldTable.ForeignKeys {
+			if exists && (!newTable.hasForeignKey(foreign) || foreignKeyRetyped(oldByName, newByName, oldTable.Name, foreign)) || !exists && foreign.Deferred {
+				clauses = append(clauses, "DROP FOREIGN KEY "+dialect.QuoteIdentifier(oldTable.ForeignKeyName(foreign)))
+			}
+		}
+		if err := outputSqlAlterTable(oldTable.Name, clauses, config); err != nil {
+			return err
+		}
+	}
+
+	for i := len(oldTables) - 1; i >= 0; i-- {
+		if _, exists := newByName[oldTables[i].Name]; !exists {
+			if err := config.WriteSqlF("DROP TABLE %s;%s%s", dialect.QuoteIdentifier(oldTables[i].Name), config.Eol, config.Eol); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, newTable := range newTables {
+		if oldTable, exists := oldByName[newTable.Name]; exists {
+			clauses := diffTable(oldTable, newTable)
+			if options := diffTableOptions(config.tableOptions(oldTable), config.tableOptions(newTable)); options != "" {
+				clauses = append(clauses, options)
+			}
+			if err := outputSqlAlterTable(newTable.Name, clauses, config); err != nil {
+				return err
+			}
+		} else {
+			if err := schema.outputSqlTable(newTable, config); err != nil {
+				return err
+			}
+			if err := config.WriteSqlReturnIndent(0); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, newTable := range newTables {
+		oldTable, exists := oldByName[newTable.Name]
+		clauses := []string{}
+		for _, foreign := range newTable.ForeignKeys {
+			if exists && (!oldTable.hasForeignKey(foreign) || foreignKeyRetyped(oldByName, newByName, newTable.Name, foreign)) || !exists && foreign.Deferred {
+				clauses = append(clauses, "ADD "+foreignKeyClause(newTable, foreign, dialect))
+			}
+			if exists {
+				schema.checkAddedForeignKey(oldTable, newTable, foreign)
+			}
+		}
+		if err := outputSqlAlterTable(newTable.Name, clauses, config); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diffTable returns the ALTER TABLE clauses changing the columns and keys of oldTable to those of newTable.
+// Foreign keys are not included.
+func diffTable(oldTable *Table, newTable *Table) []string {
+	dialect := MysqlDialect{}
+	clauses := []string{}
+
+	primaryKeyChanged := !stringSlicesEqual(oldTable.PrimaryKeys, newTable.PrimaryKeys)
+	if primaryKeyChanged && len(oldTable.PrimaryKeys) > 0 {
+		clauses = append(clauses, "DROP PRIMARY KEY")
+	}
+	for _, indexName := range sortedKeyNames(oldTable.UniqueKeys) {
+		if columns, exists := newTable.UniqueKeys[indexName]; !exists || !stringSlicesEqual(columns, oldTable.UniqueKeys[indexName]) {
+			clauses = append(clauses, "DROP INDEX "+dialect.QuoteIdentifier(indexName))
+		}
+	}
+	for _, indexName := range sortedKeyNames(oldTable.CompositeKeys) {
+		if columns, exists := newTable.CompositeKeys[indexName]; !exists || !stringSlicesEqual(columns, oldTable.CompositeKeys[indexName]) {
+			clauses = append(clauses, "DROP INDEX "+dialect.QuoteIdentifier(indexName))
+		}
+	}
+
+	for _, oldField := range oldTable.SimpleFields {
+		if newTable.FindField(oldField.Name) == nil {
+			clauses = append(clauses, "DROP COLUMN "+dialect.QuoteIdentifier(oldField.Name))
+		}
+	}
+	for _, newField := range newTable.SimpleFields {
+		oldField := oldTable.FindField(newField.Name)
+		if oldField == nil {
+			clauses = append(clauses, "ADD COLUMN "+columnDefinition(newField))
+		} else if !oldField.sameDefinition(newField) {
+			clauses = append(clauses, "MODIFY COLUMN "+columnDefinition(newField))
+		}
+	}
+
+	if primaryKeyChanged && len(newTable.PrimaryKeys) > 0 {
+		clauses = append(clauses, "ADD "+primaryKeyClause(dialect, newTable.PrimaryKeys))
+	}
+	for _, indexName := range sortedKeyNames(newTable.UniqueKeys) {
+		if columns, exists := oldTable.UniqueKeys[indexName]; !exists || !stringSlicesEqual(columns, newTable.UniqueKeys[indexName]) {
+			clauses = append(clauses, "ADD "+dialect.UniqueKey(newTable, indexName, newTable.UniqueKeys[indexName]))
+		}
+	}
+	for _, indexName := range sortedKeyNames(newTable.CompositeKeys) {
+		if columns, exists := oldTable.CompositeKeys[indexName]; !exists || !stringSlicesEqual(columns, newTable.CompositeKeys[indexName]) {
+			declaration, _ := dialect.CompositeKey(newTable, indexName, newTable.CompositeKeys[indexName])
+			clauses = append(clauses, "ADD "+declaration)
+		}
+	}
+
+	return clauses
+}
+
+func outputSqlAlterTable(tableName string, clauses []string, config GeneratorConfig) error {
+	if len(clauses) == 0 {
+		return nil
+	}
+
+	if err := config.WriteSqlF("ALTER TABLE %s", MysqlDialect{}.QuoteIdentifier(tableName)); err != nil {
+		return err
+	}
+	for i, clause := range clauses {
+		if i > 0 {
+			if err := config.WriteSql(","); err != nil {
+				return err
+			}
+		}
+		if err := config.WriteSqlReturnIndent(1); err != nil {
+			return err
+		}
+		if err := config.WriteSql(clause); err != nil {
+			return err
+		}
+	}
+	return config.WriteSqlF(";%s%s", config.Eol, config.Eol)
+}
+
+// columnDefinition returns the definition of a column in ADD COLUMN and MODIFY COLUMN clauses, which is not aligned with other columns.
+func columnDefinition(field *MysqlField) string {
+	dialect := MysqlDialect{}
+	parts := []string{dialect.QuoteIdentifier(field.Name)}
+	for _, part := range dialect.ColumnDefinition(field) {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (field *MysqlField) sameDefinition(other *MysqlField) bool {
+	return field.Type == other.Type && field.Nullable == other.Nullable && field.AutoIncrement == other.AutoIncrement &&
+		field.Default == other.Default && field.OnUpdate == other.OnUpdate && field.Comment == other.Comment &&
+		(field.JsonPath == nil) == (other.JsonPath == nil) && (field.JsonPath == nil || *field.JsonPath == *other.JsonPath)
+}
+
+func (table *Table) hasForeignKey(foreign ForeignKey) bool {
+	for _, candidate := range table.ForeignKeys {
+		if candidate.RefTable == foreign.RefTable &&
+			stringSlicesEqual(candidate.SourceColumns, foreign.SourceColumns) &&
+			stringSlicesEqual(candidate.RefColumns, foreign.RefColumns) &&
+			candidate.OnUpdate == foreign.OnUpdate && candidate.OnDelete == foreign.OnDelete {
+			return true
+		}
+	}
+	return false
+}
+
+// foreignKeyRetyped returns whether the type of a source column of foreign in the table named tableName,
+// or the type of a column it references, differs between the old and the new tables.
+func foreignKeyRetyped(oldByName map[string]*Table, newByName map[string]*Table, tableName string, foreign ForeignKey) bool {
+	for _, columns := range [...]struct {
+		table   string
+		columns []string
+	}{{tableName, foreign.SourceColumns}, {foreign.RefTable, foreign.RefColumns}} {
+		oldTable, oldExists := oldByName[columns.table]
+		newTable, newExists := newByName[columns.table]
+		if !oldExists || !newExists {
+			continue
+		}
+		for _, column := range columns.columns {
+			oldField, newField := oldTable.FindField(column), newTable.FindField(column)
+			if oldField != nil && newField != nil && oldField.Type != newField.Type {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkAddedForeignKey reports a warning if foreign of newTable is added on a new NOT NULL column,
+// since the column of the rows already in the table is filled with a value that does not reference any row.
+func (schema *Schema) checkAddedForeignKey(oldTable *Table, newTable *Table, foreign ForeignKey) {
+	for _, column := range foreign.SourceColumns {
+		if field := newTable.FindField(column); field == nil || field.Nullable || oldTable.FindField(column) != nil {
+			continue
+		}
+		owner := schema.tableOwning(newTable)
+		if owner == nil || owner.Type == nil {
+			return // the schema was not built from models
+		}
+		schema.reportField(SeverityWarning, owner, foreign.Edge, "",
+			"the migration adds the NOT NULL column %s to the existing table %s, so adding the foreign key fails if the table has rows; "+
+				"add the nullable tag and fill in the column first, or empty the table", column, newTable.Name)
+		return
+	}
+}
+
+// tableOwning returns the main table of the model whose table or auxiliary table is table, or nil if table has no model.
+func (schema *Schema) tableOwning(table *Table) *MainTable {
+	for _, mainTable := range schema.Tables {
+		if mainTable.Table == table {
+			return mainTable
+		}
+		for _, aux := range mainTable.AuxTables {
+			if aux == table {
+				return mainTable
+			}
+		}
+	}
+	return nil
+}
+
+func tablesByName(tables []*Table) map[string]*Table {
+	byName := make(map[string]*Table, len(tables))
+	for _, table := range tables {
+		byName[table.Name] = table
+	}
+	return byName
+}
+
+func stringSlicesEqual(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type migrationAccountV1 struct {
+	_    struct{} `table:"Account"`
+	Id   uint32   `primaryKey:"" autoIncrement:""`
+	Name string   `width:"32"`
+	Nick string   `width:"16"`
+	Team *migrationTeam
+}
+
+type migrationTeam struct {
+	_  struct{} `table:"Team"`
+	Id uint32   `primaryKey:"" autoIncrement:""`
+}
+
+type migrationAccountV2 struct {
+	_     struct{} `table:"Account" engine:"InnoDB"`
+	Id    uint32   `primaryKey:"" autoIncrement:""`
+	Name  string   `width:"64" unique:"name"`
+	Email *string  `width:"128"`
+	Squad *migrationSquad
+}
+
+type migrationSquad struct {
+	_  struct{} `table:"Squad"`
+	Id uint32   `primaryKey:"" autoIncrement:""`
+}
+
+type migrationAccountV3 struct {
+	_    struct{} `table:"Account"`
+	Id   uint32   `primaryKey:"" autoIncrement:""`
+	Name string   `width:"32"`
+	Nick string   `width:"16"`
+	Team *migrationWideTeam
+}
+
+type migrationWideTeam struct {
+	_  struct{} `table:"Team"`
+	Id uint64   `primaryKey:"" autoIncrement:""`
+}
+
+// outputMigration returns the migration from the schema of previous to the schema of current.
+func outputMigration(t *testing.T, previous interface{}, current interface{}) string {
+	t.Helper()
+	oldSchema, err := BuildSchema([]reflect.Type{reflect.TypeOf(previous)})
+	if err != nil {
+		t.Fatalf("build previous schema: %v", err)
+	}
+	newSchema, err := BuildSchema([]reflect.Type{reflect.TypeOf(current)})
+	if err != nil {
+		t.Fatalf("build current schema: %v", err)
+	}
+	migration := &strings.Builder{}
+	if err := newSchema.OutputMigration(oldSchema, GeneratorConfig{Indent: "\t", Eol: "\n", SqlStream: migration}); err != nil {
+		t.Fatalf("output migration: %v", err)
+	}
+	return migration.String()
+}
+
+func TestOutputMigration(t *testing.T) {
+	migration := outputMigration(t, &migrationAccountV1{}, &migrationAccountV2{})
+	expected := strings.Join([]string{
+		"ALTER TABLE `Account`",
+		"\tDROP FOREIGN KEY `fk_Account_Team_Id`;",
+		"",
+		"DROP TABLE `Team`;",
+		"",
+		"CREATE TABLE `Squad` (",
+		"\t`Id` INT UNSIGNED NOT NULL AUTO_INCREMENT,",
+		"\tPRIMARY KEY (`Id`)",
+		");",
+		"",
+		"ALTER TABLE `Account`",
+		"\tDROP COLUMN `Nick`,",
+		"\tDROP COLUMN `Team_Id`,",
+		"\tMODIFY COLUMN `Name` VARCHAR(64) NOT NULL,",
+		"\tADD COLUMN `Email` VARCHAR(128),",
+		"\tADD COLUMN `Squad_Id` INT UNSIGNED NOT NULL,",
+		"\tADD UNIQUE KEY `name` (`Name`),",
+		"\tENGINE=InnoDB;",
+		"",
+		"ALTER TABLE `Account`",
+		"\tADD CONSTRAINT `fk_Account_Squad_Id` FOREIGN KEY (`Squad_Id`) REFERENCES `Squad`(`Id`) ON UPDATE RESTRICT ON DELETE RESTRICT;",
+		"",
+		"",
+	}, "\n")
+	if migration != expected {
+		t.Errorf("expected migration\n%s\ngot\n%s", expected, migration)
+	}
+}
+
+func TestOutputMigrationWarnsNotNullForeignKeys(t *testing.T) {
+	oldSchema, err := BuildSchema([]reflect.Type{reflect.TypeOf(&migrationAccountV1{})})
+	if err != nil {
+		t.Fatalf("build previous schema: %v", err)
+	}
+	newSchema, err := BuildSchema([]reflect.Type{reflect.TypeOf(&migrationAccountV2{})})
+	if err != nil {
+		t.Fatalf("build current schema: %v", err)
+	}
+	if err := newSchema.OutputMigration(oldSchema, GeneratorConfig{Indent: "\t", Eol: "\n", SqlStream: &strings.Builder{}}); err != nil {
+		t.Fatalf("output migration: %v", err)
+	}
+	diagnostics := newSchema.Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Severity != SeverityWarning || diagnostics[0].Field != "Squad" || !strings.Contains(diagnostics[0].Message, "Squad_Id") {
+		t.Errorf("expected a warning about the NOT NULL column Squad_Id added to Account, got %v", diagnostics)
+	}
+}
+
+func TestOutputMigrationRetypedForeignKey(t *testing.T) {
+	migration := outputMigration(t, &migrationAccountV1{}, &migrationAccountV3{})
+	expected := strings.Join([]string{
+		"ALTER TABLE `Account`",
+		"\tDROP FOREIGN KEY `fk_Account_Team_Id`;",
+		"",
+		"ALTER TABLE `Team`",
+		"\tMODIFY COLUMN `Id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT;",
+		"",
+		"ALTER TABLE `Account`",
+		"\tMODIFY COLUMN `Team_Id` BIGINT UNSIGNED NOT NULL;",
+		"",
+		"ALTER TABLE `Account`",
+		"\tADD CONSTRAINT `fk_Account_Team_Id` FOREIGN KEY (`Team_Id`) REFERENCES `Team`(`Id`) ON UPDATE RESTRICT ON DELETE RESTRICT;",
+		"",
+		"",
+	}, "\n")
+	if migration != expected {
+		t.Errorf("expected the foreign key to be dropped while its columns change types\n%s\ngot\n%s", expected, migration)
+	}
+}
+
+func TestOutputMigrationUnchanged(t *testing.T) {
+	if migration := outputMigration(t, &migrationAccountV2{}, &migrationAccountV2{}); migration != "" {
+		t.Errorf("expected no statements between identical schemas, got\n%s", migration)
+	}
+}
+
+func TestOutputMigrationOtherDialects(t *testing.T) {
+	schema, err := BuildSchema([]reflect.Type{reflect.TypeOf(&migrationAccountV2{})})
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	config := GeneratorConfig{Indent: "\t", Eol: "\n", SqlStream: &strings.Builder{}, Dialect: PostgresDialect{}}
+	if err := schema.OutputMigration(schema, config); err == nil {
+		t.Error("expected migrations in the PostgreSQL dialect to be rejected")
+	}
+}
+
+func TestColumnDefinition(t *testing.T) {
+	field := &MysqlField{Name: "Id", Type: "INT UNSIGNED", AutoIncrement: true, Comment: "key"}
+	if definition := columnDefinition(field); definition != "`Id` INT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'key'" {
+		t.Errorf("expected the clauses of the column separated by single spaces, got %q", definition)
+	}
+}
@@ -0,0 +1,598 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"fmt"
+	"github.com/hoop33/go-elvis"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// ModelDirective annotates the structs written by OutputModels, so that the mymodel command picks them up as models.
+const ModelDirective = "//mymodel:model"
+
+// modelStruct is a struct written by OutputModels.
+type modelStruct struct {
+	table  *MainTable
+	name   string
+	aux    bool                // whether the table is written as a slice in another struct instead
+	parent bool                // whether another struct contains this struct
+	edges  map[string]string   // field declarations replacing foreign key columns, by the first column of the key
+	edged  map[string]bool     // the columns replaced by edges
+	slices []string            // field declarations following the columns
+	todos  map[string][]string // the differences between the table and the schema of the model, by column
+}
+
+// OutputModels writes the declarations of model structs to config.GoStream, from which the schema can be generated again.
+// This is intended to adopt the library on an existing database, usually with a schema from ParseDdl.
+//
+// Auxiliary tables in the form written by this library are turned back into slices.
+// Foreign keys on columns named <Field>_<key> become pointers to the referenced model, which is the parent
+// if the columns are NOT NULL and the foreign key cascades both deletions and updates, unless the model references itself.
+// Nullable foreign keys are declared with the nullable tag, and reference options differing from those
+// the pointer would get by default are declared with the onDelete and onUpdate tags.
+// Columns named after ImplicitParentEdge reference the parent of a model without such a pointer,
+// and a column <Slice>_Position unique together with the columns referencing the parent makes the slice of the parent ordered.
+// Other foreign keys are written as plain columns, with a TODO comment since the models do not declare them.
+// The warnings of ParseDdl about the columns, such as types converted to others, are written as TODO comments too.
+// The other differences between the tables and the models, such as columns in more than one index and
+// constraint names the models cannot declare, are added to Schema.Diagnostics as warnings.
+// Names that are not valid field names are converted to camel case, with the column tag keeping the original name.
+func (schema *Schema) OutputModels(config GeneratorConfig) error {
+	tables := schema.getSortedTables()
+	models := make(map[string]*modelStruct, len(tables))
+	for _, table := range tables {
+		models[table.Name] = &modelStruct{table: table, name: goExportedName(table.Name), edges: map[string]string{}, edged: map[string]bool{}, todos: map[string][]string{}}
+	}
+
+	writer := &goWriter{imports: map[string]bool{}}
+
+	for _, aux := range tables {
+		for _, owner := range tables {
+			fieldName := strings.TrimPrefix(aux.Name, owner.Name+"_")
+			if owner == aux || fieldName == aux.Name || !isModelFieldName(fieldName) {
+				continue
+			}
+			if value, ordered, ok := valueListColumns(owner, aux.Table); ok {
+				goType, tags, err := modelGoType(value, writer)
+				if err != nil {
+					return fmt.Errorf("%s.%s: %v", aux.Name, value.Name, err)
+				}
+				if ordered {
+					tags = append(tags, `ordered:""`)
+				}
+				models[owner.Name].slices = append(models[owner.Name].slices, modelFieldDeclaration(fieldName, "[]"+goType, tags, nil))
+				models[aux.Name].aux = true
+				break
+			}
+			if peer := multiMultiPeer(owner, aux.Table, tables); peer != nil {
+				var tags []string
+				for _, foreign := range aux.ForeignKeys {
+					if foreign.RefTable == peer.Name && foreign.SourceColumns[0] == aux.SimpleFields[len(owner.PrimaryKeys)].Name {
+						tags = referenceOptionTags(foreign, ReferenceOptionCascade)
+					}
+				}
+				models[owner.Name].slices = append(models[owner.Name].slices, modelFieldDeclaration(fieldName, "[]*"+models[peer.Name].name, tags, nil))
+				models[aux.Name].aux = true
+				break
+			}
+		}
+	}
+
+	var warnings Diagnostics
+	for _, table := range tables {
+		model := models[table.Name]
+		if model.aux {
+			continue
+		}
+		for _, foreign := range table.ForeignKeys {
+			reason := model.addEdge(table, foreign, models)
+			if reason != "" {
+				model.todo(foreign.SourceColumns[0], "the foreign key of %s referencing %s is not declared, because %s", strings.Join(foreign.SourceColumns, ", "), foreign.RefTable, reason)
+				continue
+			}
+			for _, column := range foreign.SourceColumns {
+				model.edged[column] = true
+			}
+			derived := foreign
+			derived.Name = ""
+			if name := table.ForeignKeyName(derived); foreign.Name != "" && foreign.Name != name {
+				warnings = append(warnings, &Diagnostic{Severity: SeverityWarning, Type: table.Name, Field: foreign.SourceColumns[0],
+					Message: fmt.Sprintf("the foreign key %s is named %s by the models, which cannot declare constraint names", foreign.Name, name)})
+			}
+		}
+	}
+
+	for _, table := range tables {
+		model := models[table.Name]
+		if model.aux {
+			continue
+		}
+		jsonIndexes := map[string][]string{}
+		for _, field := range table.SimpleFields {
+			if field.JsonPath == nil {
+				continue
+			}
+			source := table.FindField(field.JsonPath.Column)
+			name := strings.TrimPrefix(field.Name, field.JsonPath.Column+"_")
+			if source == nil || source.Type != "JSON" || name == field.Name || !jsonIndexNamePattern.MatchString(name) {
+				return fmt.Errorf("generated column %s.%s cannot be declared with the jsonIndex tag", table.Name, field.Name)
+			}
+			jsonIndexes[source.Name] = append(jsonIndexes[source.Name], name+" "+field.JsonPath.Path+" "+field.Type)
+		}
+
+		declarations := []string{}
+		tags := modelTableOptionTags(table.Options)
+		if model.name != table.Name {
+			tags = append([]string{fmt.Sprintf("table:%q", table.Name)}, tags...)
+		}
+		if len(tags) > 0 {
+			declarations = append(declarations, modelFieldDeclaration("_", "struct{}", tags, nil))
+		}
+		for _, field := range table.SimpleFields {
+			if field.JsonPath != nil {
+				continue // declared in the jsonIndex tag of its JSON column
+			}
+			if model.edged[field.Name] {
+				if declaration, exists := model.edges[field.Name]; exists {
+					declarations = append(declarations, declaration)
+				}
+				continue
+			}
+			goType, tags, err := modelGoType(field, writer)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %v", table.Name, field.Name, err)
+			}
+			if indexes, exists := jsonIndexes[field.Name]; exists {
+				tags = append(tags, `jsonIndex:"`+strings.Join(indexes, "; ")+`"`)
+			}
+			if field.Default != "" {
+				tags = append(tags, fmt.Sprintf("default:%q", defaultTagValue(field.Default)))
+			}
+			if field.OnUpdate != "" {
+				tags = append(tags, fmt.Sprintf("onUpdate:%q", field.OnUpdate))
+			}
+			if field.Comment != "" {
+				tags = append(tags, fmt.Sprintf("comment:%q", field.Comment))
+			}
+			keyTags, todos := modelKeyTags(table.Table, field)
+			comments := []string{}
+			for _, diagnostic := range schema.diagnostics {
+				if diagnostic.Type == table.Name && diagnostic.Field == field.Name {
+					comments = append(comments, "TODO: "+diagnostic.Message)
+				}
+			}
+			for _, todo := range append(todos, model.todos[field.Name]...) {
+				comments = append(comments, "TODO: "+todo)
+				warnings = append(warnings, &Diagnostic{Severity: SeverityWarning, Type: table.Name, Field: field.Name, Message: todo})
+			}
+			name := goExportedName(field.Name)
+			if name != field.Name {
+				tags = append(tags, fmt.Sprintf("column:%q", field.Name))
+			}
+			declarations = append(declarations, modelFieldDeclaration(name, goType, append(keyTags, tags...), comments))
+		}
+		declarations = append(declarations, model.slices...)
+
+		names := map[string]bool{}
+		for _, declaration := range declarations {
+			name := strings.Fields(declaration)[0]
+			if names[name] {
+				return fmt.Errorf("table %s has more than one field called %s", table.Name, name)
+			}
+			names[name] = true
+		}
+
+		writer.printf("\n// %s models the %s table.\n%s\ntype %s struct {\n", model.name, table.Name, ModelDirective, model.name)
+		for _, declaration := range declarations {
+			writer.printf("\t%s\n", declaration)
+		}
+		writer.printf("}\n")
+	}
+	schema.diagnostics = append(schema.diagnostics, warnings...)
+
+	return writer.writeFile(config, "// Code generated by my-model from an existing schema. Review the models before editing them.")
+}
+
+// todo records a difference between the table and the schema of the model, which is commented on the field declaring column.
+func (model *modelStruct) todo(column string, format string, args ...interface{}) {
+	model.todos[column] = append(model.todos[column], fmt.Sprintf(format, args...))
+}
+
+// addEdge declares foreign as a pointer to the referenced model,
+// or returns the reason why the model cannot declare it.
+func (model *modelStruct) addEdge(table *MainTable, foreign ForeignKey, models map[string]*modelStruct) string {
+	peer, exists := models[foreign.RefTable]
+	if !exists || peer.aux {
+		return "it references a table that is not a model"
+	}
+	edgeName, ok := foreignKeyEdgeName(foreign, peer.table)
+	if !ok {
+		return "its columns are not named <Field>_<key> after the primary keys of " + foreign.RefTable
+	}
+	implicit := edgeName == ImplicitParentEdge // the child has no field referencing the parent
+	name := goExportedName(edgeName)
+	var columnTags []string
+	if name != edgeName && !implicit {
+		columnTags = append(columnTags, fmt.Sprintf("column:%q", edgeName))
+	}
+	nullable := table.FindField(foreign.SourceColumns[0]).Nullable
+
+	// only the foreign keys of children are NOT NULL and cascade both deletions and updates
+	position, sliceName := "", model.name
+	isParent := foreign.OnDelete == ReferenceOptionCascade && foreign.OnUpdate == ReferenceOptionCascade && !nullable && peer != model && !model.parent
+	if isParent {
+		position, sliceName = childPositionColumn(table.Table, foreign, sliceName)
+	}
+	inPrimaryKey, inIndex := keyMembership(table.Table, foreign.SourceColumns, position)
+	if isParent && !inIndex && (!inPrimaryKey || edgeName == peer.name) {
+		tags := []string{`parent:""`}
+		if inPrimaryKey {
+			tags = append(tags, `primaryKey:""`)
+		}
+		if !implicit {
+			model.edges[foreign.SourceColumns[0]] = modelFieldDeclaration(name, "*"+peer.name, append(tags, columnTags...), nil)
+		}
+		model.parent = true
+		// a child in a single field cannot be told apart from a slice of children in the schema
+		var sliceTags []string
+		if position != "" {
+			sliceTags = append(sliceTags, `ordered:""`)
+			model.edged[position] = true
+		}
+		peer.slices = append(peer.slices, modelFieldDeclaration(sliceName, "[]"+model.name, sliceTags, nil))
+		return ""
+	}
+	if implicit {
+		return "its columns are named after the parent of a child"
+	}
+	if inPrimaryKey, inIndex = keyMembership(table.Table, foreign.SourceColumns, ""); inPrimaryKey || inIndex {
+		return "its columns are in the primary key or another index"
+	}
+
+	var tags, comments []string
+	if peer == model {
+		if !nullable {
+			comments = append(comments, "TODO: the columns are NOT NULL, but the columns referencing the model itself are nullable")
+		}
+		nullable = true // the columns referencing the model itself are always nullable
+	} else if nullable {
+		tags = append(tags, `nullable:""`)
+	}
+	tags = append(tags, referenceOptionTags(foreign, elvis.Ternary(nullable, ReferenceOptionSetNull, ReferenceOptionRestrict).(ReferenceOption))...)
+	model.edges[foreign.SourceColumns[0]] = modelFieldDeclaration(name, "*"+peer.name, append(tags, columnTags...), comments)
+	return ""
+}
+
+func modelFieldDeclaration(name string, goType string, tags []string, comments []string) string {
+	declaration := name + " " + goType
+	if len(tags) > 0 {
+		declaration += " `" + strings.Join(tags, " ") + "`"
+	}
+	if len(comments) > 0 {
+		declaration += " // " + strings.Join(comments, "; ")
+	}
+	return declaration
+}
+
+// modelTableOptionTags returns the tags of the marker field declaring the options of a table.
+func modelTableOptionTags(options TableOptions) []string {
+	tags := []string{}
+	for _, option := range []struct{ tag, value string }{
+		{"engine", options.Engine},
+		{"charset", options.Charset},
+		{"collate", options.Collate},
+		{"rowFormat", options.RowFormat},
+		{"comment", options.Comment},
+	} {
+		if option.value != "" {
+			tags = append(tags, fmt.Sprintf("%s:%q", option.tag, option.value))
+		}
+	}
+	return tags
+}
+
+// modelKeyTags returns the tag declaring the first key containing field, and the differences naming the other keys,
+// since a field can only be declared in one key.
+func modelKeyTags(table *Table, field *MysqlField) ([]string, []string) {
+	tags := []string{}
+	todos := []string{}
+	for _, key := range table.PrimaryKeys {
+		if key == field.Name {
+			tags = append(tags, `primaryKey:""`)
+			if field.AutoIncrement {
+				tags = append(tags, `autoIncrement:""`)
+			}
+		}
+	}
+	for _, keys := range []struct {
+		tag  string
+		keys map[string][]string
+	}{{"unique", table.UniqueKeys}, {"composite", table.CompositeKeys}} {
+		for _, indexName := range sortedKeyNames(keys.keys) {
+			for _, column := range keys.keys[indexName] {
+				if column != field.Name {
+					continue
+				}
+				if len(tags) == 0 {
+					tags = append(tags, fmt.Sprintf("%s:%q", keys.tag, indexName))
+				} else {
+					todos = append(todos, "the index "+indexName+" does not contain this column, because a field can only be declared in one index")
+				}
+			}
+		}
+	}
+	return tags, todos
+}
+
+// modelGoType returns the type and the tags of the field declaring a column, as understood by SimpleToMysqlType.
+func modelGoType(field *MysqlField, writer *goWriter) (string, []string, error) {
+	goType := ""
+	tags := []string{}
+	switch field.Type {
+	case "BOOL":
+		goType = "bool"
+	case "TINYINT SIGNED":
+		goType = "int8"
+	case "SMALLINT SIGNED":
+		goType = "int16"
+	case "INT SIGNED":
+		goType = "int32"
+	case "BIGINT SIGNED":
+		goType = "int64"
+	case "TINYINT UNSIGNED":
+		goType = "uint8"
+	case "SMALLINT UNSIGNED":
+		goType = "uint16"
+	case "INT UNSIGNED":
+		goType = "uint32"
+	case "BIGINT UNSIGNED":
+		goType = "uint64"
+	case "FLOAT":
+		goType = "float32"
+	case "DOUBLE":
+		goType = "float64"
+	case "TINYTEXT":
+		goType, tags = "string", []string{`text:"tiny"`}
+	case "TEXT":
+		goType, tags = "string", []string{`text:""`}
+	case "MEDIUMTEXT":
+		goType, tags = "string", []string{`text:"medium"`}
+	case "LONGTEXT":
+		goType, tags = "string", []string{`text:"long"`}
+	case "TINYBLOB":
+		goType, tags = "[]byte", []string{`blob:"tiny"`}
+	case "BLOB":
+		goType, tags = "[]byte", []string{`blob:""`}
+	case "MEDIUMBLOB":
+		goType, tags = "[]byte", []string{`blob:"medium"`}
+	case "LONGBLOB":
+		goType, tags = "[]byte", []string{`blob:"long"`}
+	case "TIMESTAMP":
+		goType = "time.Time"
+		writer.imports["time"] = true
+	case "JSON":
+		goType, tags = "json.RawMessage", []string{`jsonColumn:""`}
+		writer.imports["encoding/json"] = true
+	default:
+		if len(field.Values) > 0 {
+			for _, value := range field.Values {
+				if strings.ContainsAny(value, ",\"`") {
+					return "", nil, fmt.Errorf("enum value %q cannot be declared in a tag", value)
+				}
+			}
+			goType, tags = "string", []string{`enum:"` + strings.Join(field.Values, ",") + `"`}
+			if isSetType(field.Type) {
+				goType = "[]string"
+			}
+			break
+		}
+		var width string
+		if _, err := fmt.Sscanf(field.Type, "DECIMAL(%s", &width); err == nil {
+			goType, tags = "string", []string{`decimal:"` + strings.TrimSuffix(width, ")") + `"`}
+		} else if _, err := fmt.Sscanf(field.Type, "VARBINARY(%s", &width); err == nil {
+			goType, tags = "[]byte", []string{`width:"` + strings.TrimSuffix(width, ")") + `"`}
+		} else if _, err := fmt.Sscanf(field.Type, "BINARY(%s", &width); err == nil {
+			goType = "[" + strings.TrimSuffix(width, ")") + "]byte"
+		} else if _, err := fmt.Sscanf(field.Type, "VARCHAR(%s", &width); err == nil {
+			goType, tags = "string", []string{`width:"` + strings.TrimSuffix(width, ")") + `"`}
+		} else if _, err := fmt.Sscanf(field.Type, "CHAR(%s", &width); err == nil {
+			goType, tags = "string", []string{`fixed:""`, `width:"` + strings.TrimSuffix(width, ")") + `"`}
+		} else {
+			return "", nil, fmt.Errorf("type %s has no Go equivalent", field.Type)
+		}
+	}
+	if field.Nullable && !isSetType(field.Type) {
+		goType = "*" + goType
+	}
+	return goType, tags, nil
+}
+
+// valueListColumns checks whether aux is the auxiliary table of a value list in owner, as created by computeEdges,
+// and returns the value column and whether the list is ordered.
+func valueListColumns(owner *MainTable, aux *Table) (*MysqlField, bool, bool) {
+	keyCount := len(owner.PrimaryKeys)
+	if keyCount == 0 || !hasOwnerColumns(owner, aux) || len(aux.UniqueKeys) > 0 || len(aux.CompositeKeys) > 0 {
+		return nil, false, false
+	}
+	for _, foreign := range aux.ForeignKeys {
+		if foreign.RefTable != owner.Name {
+			return nil, false, false
+		}
+	}
+	rest := aux.SimpleFields[keyCount:]
+	ordered := len(rest) == 2 && rest[0].Name == ValueListOrdinalColumn
+	if len(rest) != elvis.Ternary(ordered, 2, 1).(int) || rest[len(rest)-1].Name != ValueListValueColumn {
+		return nil, false, false
+	}
+	if ordered {
+		primaryKeys := []string{}
+		for _, field := range aux.SimpleFields[:keyCount+1] {
+			primaryKeys = append(primaryKeys, field.Name)
+		}
+		if !stringSlicesEqual(aux.PrimaryKeys, primaryKeys) {
+			return nil, false, false
+		}
+	} else if len(aux.PrimaryKeys) > 0 {
+		return nil, false, false
+	}
+	return rest[len(rest)-1], ordered, true
+}
+
+// referenceOptionTags returns the onDelete and onUpdate tags declaring the reference options of foreign
+// which differ from fallback and updateFallback(fallback).
+func referenceOptionTags(foreign ForeignKey, fallback ReferenceOption) []string {
+	var tags []string
+	for _, option := range [...]struct {
+		tag             string
+		value, fallback ReferenceOption
+	}{{"onDelete", foreign.OnDelete, fallback}, {"onUpdate", foreign.OnUpdate, updateFallback(fallback)}} {
+		if option.value != option.fallback {
+			tags = append(tags, fmt.Sprintf("%s:%q", option.tag, strings.ToLower(strings.Replace(string(option.value), " ", "", -1))))
+		}
+	}
+	return tags
+}
+
+// multiMultiPeer returns the table referenced by owner through aux, if aux is the auxiliary table of a multi-multi edge.
+func multiMultiPeer(owner *MainTable, aux *Table, tables []*MainTable) *MainTable {
+	if len(owner.PrimaryKeys) == 0 || !hasOwnerColumns(owner, aux) || len(aux.UniqueKeys) > 0 || len(aux.CompositeKeys) > 0 {
+		return nil
+	}
+	columns := make([]string, 0, len(aux.SimpleFields))
+	for _, field := range aux.SimpleFields {
+		columns = append(columns, field.Name)
+	}
+	if !stringSlicesEqual(aux.PrimaryKeys, columns) {
+		return nil
+	}
+	rest := aux.SimpleFields[len(owner.PrimaryKeys):]
+	for _, peer := range tables {
+		prefix := peer.Name
+		if peer == owner {
+			prefix = strings.TrimPrefix(aux.Name, owner.Name+"_") // the columns referencing the owner itself are named after the field
+		}
+		if len(peer.PrimaryKeys) == 0 || len(peer.PrimaryKeys) != len(rest) || peer.Name == aux.Name {
+			continue
+		}
+		matches := true
+		for i, key := range peer.PrimaryKeys {
+			field := peer.FindField(key)
+			if field == nil || rest[i].Name != prefix+"_"+key || rest[i].Type != field.Type {
+				matches = false
+			}
+		}
+		if matches {
+			return peer
+		}
+	}
+	return nil
+}
+
+// hasOwnerColumns checks whether the first columns of aux are the primary keys of owner, prefixed with its name.
+func hasOwnerColumns(owner *MainTable, aux *Table) bool {
+	if len(aux.SimpleFields) <= len(owner.PrimaryKeys) {
+		return false
+	}
+	for i, key := range owner.PrimaryKeys {
+		field := owner.FindField(key)
+		if field == nil || aux.SimpleFields[i].Name != owner.Name+"_"+key || aux.SimpleFields[i].Type != field.Type {
+			return false
+		}
+	}
+	return true
+}
+
+// foreignKeyEdgeName returns the field name of a foreign key whose columns are named <Field>_<key> for the primary keys of peer.
+func foreignKeyEdgeName(foreign ForeignKey, peer *MainTable) (string, bool) {
+	if !stringSlicesEqual(foreign.RefColumns, peer.PrimaryKeys) {
+		return "", false
+	}
+	edgeName := ""
+	for i, column := range foreign.SourceColumns {
+		suffix := "_" + foreign.RefColumns[i]
+		if !strings.HasSuffix(column, suffix) {
+			return "", false
+		}
+		name := strings.TrimSuffix(column, suffix)
+		if i > 0 && name != edgeName || !isModelFieldName(name) && name != ImplicitParentEdge {
+			return "", false
+		}
+		edgeName = name
+	}
+	return edgeName, true
+}
+
+// childPositionColumn returns the column storing the positions of the rows referencing the parent through foreign in an ordered slice,
+// which is also the name of its unique key, and the name of the slice, or fallback if there is no such column.
+func childPositionColumn(table *Table, foreign ForeignKey, fallback string) (string, string) {
+	for _, name := range sortedKeyNames(table.UniqueKeys) {
+		slice := strings.TrimSuffix(name, "_"+ChildPositionColumn)
+		field := table.FindField(name)
+		if slice != name && isModelFieldName(slice) && field != nil && field.Type == "INT SIGNED" &&
+			stringSlicesEqual(table.UniqueKeys[name], append(append([]string{}, foreign.SourceColumns...), name)) {
+			return name, slice
+		}
+	}
+	return "", fallback
+}
+
+// keyMembership reports whether any of columns is a primary key, and whether any of them is in another index.
+// A non-unique index of exactly the columns is not counted, since MySQL creates such an index for each foreign key.
+func keyMembership(table *Table, columns []string, ignoredIndex string) (bool, bool) {
+	inPrimaryKey, inIndex := false, false
+	for _, column := range columns {
+		for _, key := range table.PrimaryKeys {
+			inPrimaryKey = inPrimaryKey || key == column
+		}
+		for _, keys := range []map[string][]string{table.UniqueKeys, table.CompositeKeys} {
+			for indexName, indexColumns := range keys {
+				if indexName == ignoredIndex || stringSlicesEqual(indexColumns, columns) && table.CompositeKeys[indexName] != nil {
+					continue
+				}
+				for _, indexColumn := range indexColumns {
+					inIndex = inIndex || indexColumn == column
+				}
+			}
+		}
+	}
+	return inPrimaryKey, inIndex
+}
+
+// isModelFieldName reports whether name can be used as a field name by yieldTable.
+func isModelFieldName(name string) bool {
+	return token.IsIdentifier(name) && !strings.ContainsRune(name, '_')
+}
+
+// goExportedName converts a table or column name to an exported Go identifier in camel case.
+func goExportedName(name string) string {
+	result := ""
+	for _, piece := range strings.FieldsFunc(name, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	}) {
+		runes := []rune(piece)
+		result += string(unicode.ToUpper(runes[0])) + string(runes[1:])
+	}
+	if result == "" || !unicode.IsLetter([]rune(result)[0]) {
+		result = "X" + result
+	}
+	return result
+}
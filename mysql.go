@@ -19,33 +19,49 @@
 package myModel
 
 import (
-	"reflect"
 	"sort"
 	"github.com/SOF3/go-stable-toposort"
 	"strings"
 	"fmt"
+	"reflect"
 )
 
 type Schema struct {
 	Tables        map[string]*MainTable
 	sortedList    []*MainTable
 	graphOutdated bool
+	diagnostics   Diagnostics
+	types         *TypeRegistry  // mappings of the models to columns in addition to the default ones
+	naming        NamingStrategy // names of the tables and columns of the models
 }
 
-func (schema *Schema) getTable(typ reflect.Type) *MainTable {
-	if _, isNew := schema.Tables[typ.Name()]; !isNew {
-		schema.graphOutdated = true
-		schema.Tables[typ.Name()] = NewMainTable(typ)
-	}
-	return schema.Tables[typ.Name()]
-}
-func (schema *Schema) mustGetTable(name string) *MainTable {
+func (schema *Schema) getTable(typ TypeInfo) *MainTable {
+	name := schema.tableName(typ)
 	table, exists := schema.Tables[name]
 	if !exists {
-		panic("table " + name + " does not exist")
+		schema.graphOutdated = true
+		table = NewMainTable(typ)
+		table.Name = name
+		schema.Tables[name] = table
+	} else if table.Type.PkgPath() != typ.PkgPath() || table.Type.Name() != typ.Name() {
+		schema.report(SeverityError, table, "%s and %s are both stored in the table %s; rename one of them with the table tag", table.Type, typ, name)
 	}
 	return table
 }
+
+// tableName returns the name of the table of the model typ, from the table tag of its marker field or from the naming strategy.
+func (schema *Schema) tableName(typ TypeInfo) string {
+	if typ.Kind() == reflect.Struct {
+		for i := 0; i < typ.NumField(); i++ {
+			if field := typ.Field(i); field.Name == "_" {
+				if name, exists := field.Tag.Lookup("table"); exists && name != "" {
+					return name
+				}
+			}
+		}
+	}
+	return schema.naming.TableName(typ.Name())
+}
 func (schema *Schema) getSortedTables() []*MainTable {
 	if schema.graphOutdated {
 		tables := make([]stableToposort.Node, 0, len(schema.Tables))
@@ -58,8 +74,11 @@ func (schema *Schema) getSortedTables() []*MainTable {
 		result, err := stableToposort.Sort(tables)
 
 		if err != nil {
-			panic(fmt.Sprintf("reference cycle detected: %v", err))
-			return nil
+			schema.diagnostics = append(schema.diagnostics, &Diagnostic{
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("reference cycle detected: %v; cycles are only broken at pointer fields, not between parents and children", err),
+			})
+			result = tables // keep going in name order to find the other problems
 		}
 
 		schema.sortedList = make([]*MainTable, len(result))
@@ -71,6 +90,16 @@ func (schema *Schema) getSortedTables() []*MainTable {
 	return schema.sortedList
 }
 
+// getAllTables returns the main tables in dependency order, each followed by its auxiliary tables.
+func (schema *Schema) getAllTables() []*Table {
+	tables := make([]*Table, 0, len(schema.Tables))
+	for _, table := range schema.getSortedTables() {
+		tables = append(tables, table.Table)
+		tables = append(tables, table.AuxTables...)
+	}
+	return tables
+}
+
 type Table struct {
 	Name          string
 	SimpleFields  []*MysqlField
@@ -78,6 +107,7 @@ type Table struct {
 	UniqueKeys    map[string][]string
 	CompositeKeys map[string][]string
 	ForeignKeys   []ForeignKey
+	Options       TableOptions
 }
 
 func NewTable(name string) *Table {
@@ -90,14 +120,14 @@ func NewTable(name string) *Table {
 	}
 }
 
+// FindField returns the column called name, or nil if there is no such column.
 func (table *Table) FindField(name string) *MysqlField {
 	for _, field := range table.SimpleFields {
 		if field.Name == name {
 			return field
 		}
 	}
-
-	panic("field " + name + " not found in " + table.Name)
+	return nil
 }
 
 type MainTable struct {
@@ -105,26 +135,36 @@ type MainTable struct {
 	AuxTables []*Table
 
 	Edges       []*Edge
-	Type        reflect.Type
-	knownParent *MainTable // set from the parent type, to be validated if there is an EdgeTypeMultiOneParent
+	ValueLists  []*ValueList
+	Type        TypeInfo
+	knownParent *MainTable        // set from the parent type, to be validated if there is an EdgeTypeMultiOneParent
+	containers  []*childContainer // the fields of the parent containing the rows
 	yielded     bool
+
+	fieldPositions map[string]string // source positions of the fields of Type, for diagnostics
 }
 
-func NewMainTable(typ reflect.Type) *MainTable {
+func NewMainTable(typ TypeInfo) *MainTable {
 	return &MainTable{
-		Table:     NewTable(typ.Name()),
-		Edges:     []*Edge{},
-		AuxTables: []*Table{},
-		Type:      typ,
+		Table:      NewTable(typ.Name()),
+		Edges:      []*Edge{},
+		ValueLists: []*ValueList{},
+		AuxTables:  []*Table{},
+		Type:       typ,
+
+		fieldPositions: map[string]string{},
 	}
 }
 
 func (table *MainTable) Depends(dependency *MainTable) bool {
+	if table == dependency {
+		return false // references to the same table are checked when rows are written, not when it is created
+	}
 	if table.knownParent == dependency {
 		return true
 	}
 	for _, edge := range table.Edges {
-		if edge.Type != EdgeTypeOneOne && edge.Type != EdgeTypeOneMulti && edge.PeerTable == dependency.Name {
+		if edge.Type != EdgeTypeOneOne && edge.Type != EdgeTypeOneMulti && edge.PeerTable == dependency.Name && !edge.deferred && !edge.secondary {
 			return true
 		}
 	}
@@ -140,6 +180,9 @@ func (table *MainTable) FindEdgeByName(name string) *Edge {
 	return nil
 }
 
+// FindEdgeByPeerTable returns the first edge referencing peerTable.
+//
+// Deprecated: a model can have more than one edge to the same table; use FindEdgeByName.
 func (table *MainTable) FindEdgeByPeerTable(peerTable string) *Edge {
 	for _, edge := range table.Edges {
 		if edge.PeerTable == peerTable {
@@ -149,15 +192,67 @@ func (table *MainTable) FindEdgeByPeerTable(peerTable string) *Edge {
 	return nil
 }
 
+// hasParentEdge checks whether the model has a pointer field with the parent tag.
+func (table *MainTable) hasParentEdge() bool {
+	for _, edge := range table.Edges {
+		if edge.Type == EdgeTypeUnknownParent || edge.Type == EdgeTypeMultiOneParent || edge.Type == EdgeTypeOneOneParent {
+			return true
+		}
+	}
+	return false
+}
+
 func (table *MainTable) Before(node stableToposort.Node) bool {
 	return node.(*MainTable).Depends(table)
 }
 
+// ValueList is a slice of simple values in a model, which is stored in an auxiliary table named after the field.
+type ValueList struct {
+	Name    string
+	Value   *MysqlField
+	Ordered bool   // whether the auxiliary table has an ordinal column preserving the order of the slice
+	Table   *Table // the auxiliary table, set by computeEdges
+	column  string // name of the field as a column, which names the auxiliary table
+}
+
+// childContainer is a field of a parent model containing the rows of a child table, either as a struct or as a slice.
+type childContainer struct {
+	parent   *MainTable
+	field    string     // the field of parent containing the rows
+	multi    bool       // whether the field is a slice
+	foreign  ForeignKey // the foreign key referencing the parent row
+	position string     // the column storing the position of each row if the field is an ordered slice, empty otherwise
+}
+
+// JsonPath is the value of a generated column, which extracts a scalar from a JSON column.
+type JsonPath struct {
+	Column string
+	Path   string // a MySQL path such as $.a.b[0], consisting of member names and array indices only
+}
+
+const (
+	ValueListValueColumn   = "Value"
+	ValueListOrdinalColumn = "Ordinal"
+	ChildPositionColumn    = "Position" // prefixed with the column of the ordered slice containing the children
+)
+
 type MysqlField struct {
 	Name          string
 	Type          string
 	Nullable      bool
 	AutoIncrement bool
+	Values        []string  `json:",omitempty"` // the values allowed in ENUM and SET columns
+	JsonPath      *JsonPath `json:",omitempty"` // the value of a generated column, if any
+	Default       string    `json:",omitempty"` // the DEFAULT expression in MySQL syntax, such as 'text', 1 or CURRENT_TIMESTAMP
+	OnUpdate      string    `json:",omitempty"` // the ON UPDATE expression, if any
+	Comment       string    `json:",omitempty"`
+
+	GoName    string       // selector of the struct field backing this column, empty for generated columns
+	GoType    string       // Go type of the backing struct field, relative to the model package
+	GoImport  string       // import path required by GoType, if any
+	GoValuer  bool         `json:",omitempty"` // whether the Go type is written and scanned with its own driver.Valuer and sql.Scanner
+	GoOrdinal bool         `json:",omitempty"` // whether the Go type is an integer holding the index of the value in Values
+	GoMapping *TypeMapping `json:"-"`          // the registered mapping of the Go type, if any
 }
 
 type ForeignKey struct {
@@ -166,6 +261,9 @@ type ForeignKey struct {
 	RefColumns    []string
 	OnUpdate      ReferenceOption
 	OnDelete      ReferenceOption
+	Edge          string // the pointer field holding the referenced row, empty if the model has no such field
+	Deferred      bool   `json:",omitempty"` // whether the foreign key is added after all tables are created, because it closes a reference cycle
+	Name          string `json:",omitempty"` // the constraint name read by ParseDdl, empty if it is derived by ForeignKeyName
 }
 
 type ReferenceOption string
@@ -174,8 +272,17 @@ const (
 	ReferenceOptionRestrict ReferenceOption = "RESTRICT"
 	ReferenceOptionCascade  ReferenceOption = "CASCADE"
 	ReferenceOptionSetNull  ReferenceOption = "SET NULL"
+	ReferenceOptionNoAction ReferenceOption = "NO ACTION"
 )
 
+// ForeignKeyName returns the constraint name of a foreign key declared in this table.
+func (table *Table) ForeignKeyName(foreign ForeignKey) string {
+	if foreign.Name != "" {
+		return foreign.Name
+	}
+	return "fk_" + table.Name + "_" + strings.Join(foreign.SourceColumns, "_")
+}
+
 func MakeForeignKey(refTable string) ForeignKey {
 	return ForeignKey{
 		SourceColumns: []string{},
@@ -0,0 +1,96 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy derives the names of tables and columns from the names of the model types and fields.
+//
+// Models override the derived names with the column tag on a field, and the table tag on a blank marker field:
+//
+//	_     struct{} `table:"accounts"`
+//	Email string   `column:"email_address"`
+//
+// Derived names join the names they are derived from with underscores, so they follow the strategy too:
+// the foreign key columns of a pointer field are named after its column and the referenced columns,
+// and the auxiliary table of a slice field after the table and the column of the field.
+type NamingStrategy interface {
+	TableName(typeName string) string   // the name of the table of a model type
+	ColumnName(fieldName string) string // the name of the column of a field, whose name starts with the prefixes of the embedding fields
+}
+
+// DefaultNaming uses the names of the types and fields as is.
+type DefaultNaming struct{}
+
+func (DefaultNaming) TableName(typeName string) string {
+	return typeName
+}
+func (DefaultNaming) ColumnName(fieldName string) string {
+	return fieldName
+}
+
+// SnakeCaseNaming converts the names of types and fields to snake_case, such as user_account for UserAccount and http_proxy for HTTPProxy.
+type SnakeCaseNaming struct {
+	TablePrefix string // prepended to every table name, such as "app_"
+	Plural      bool   // whether table names are pluralized, such as user_accounts for UserAccount
+}
+
+func (naming SnakeCaseNaming) TableName(typeName string) string {
+	name := snakeCase(typeName)
+	if naming.Plural {
+		name = pluralize(name)
+	}
+	return naming.TablePrefix + name
+}
+func (naming SnakeCaseNaming) ColumnName(fieldName string) string {
+	return snakeCase(fieldName)
+}
+
+// snakeCase converts a Go identifier in camel case to lower case words separated by underscores.
+// Acronyms are kept in one word, and digits stay in the word they follow.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	builder := strings.Builder{}
+	for i, c := range runes {
+		if unicode.IsUpper(c) && i > 0 && runes[i-1] != '_' {
+			previousLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if previousLower || unicode.IsUpper(runes[i-1]) && nextLower {
+				builder.WriteRune('_')
+			}
+		}
+		builder.WriteRune(unicode.ToLower(c))
+	}
+	return builder.String()
+}
+
+// pluralize returns the plural of the last word of a snake_case name with the regular English rules.
+func pluralize(name string) string {
+	switch {
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou_", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
@@ -0,0 +1,67 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import "testing"
+
+func TestSnakeCaseNaming(t *testing.T) {
+	naming := SnakeCaseNaming{TablePrefix: "app_", Plural: true}
+	for typeName, expected := range map[string]string{
+		"User":        "app_users",
+		"UserAccount": "app_user_accounts",
+		"HTTPProxy":   "app_http_proxies",
+		"Address":     "app_addresses",
+		"Day":         "app_days",
+		"Box":         "app_boxes",
+	} {
+		if name := naming.TableName(typeName); name != expected {
+			t.Errorf("table of %s: expected %s, got %s", typeName, expected, name)
+		}
+	}
+	for fieldName, expected := range map[string]string{
+		"Id":        "id",
+		"UserID":    "user_id",
+		"Ipv4Addr":  "ipv4_addr",
+		"ShipCity":  "ship_city",
+		"Parent__":  "parent__",
+		"Level2Key": "level2_key",
+	} {
+		if name := naming.ColumnName(fieldName); name != expected {
+			t.Errorf("column of %s: expected %s, got %s", fieldName, expected, name)
+		}
+	}
+}
+
+func TestSnakeCaseDerivedNames(t *testing.T) {
+	_, _, models := sourceModels(t, ddlModels, "Account")
+	schema, err := buildSchema(models, nil, SnakeCaseNaming{Plural: true})
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	accounts := schema.Tables["accounts"]
+	if accounts == nil || schema.Tables["teams"] == nil {
+		t.Fatalf("expected the tables accounts and teams, got %v", schema.Tables)
+	}
+	if accounts.FindField("team_id") == nil {
+		t.Errorf("expected the foreign key column team_id, got %+v", accounts.SimpleFields)
+	}
+	if len(accounts.AuxTables) != 1 || accounts.AuxTables[0].Name != "accounts_tags" || accounts.AuxTables[0].FindField("account_id") == nil {
+		t.Errorf("expected the auxiliary table accounts_tags referencing account_id, got %+v", accounts.AuxTables)
+	}
+}
@@ -0,0 +1,203 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"sort"
+	"strings"
+)
+
+// deferCycles breaks the reference cycles between the models, such as two models referencing each other with pointers,
+// by deferring the foreign keys of the pointer fields closing them, which are added after all tables are created.
+// The columns of deferred pointer fields are nullable, so that the first row of a cycle can be inserted before the rows it references.
+// Cycles of parents and children cannot be broken, and are reported by getSortedTables.
+//
+// The deferred edges are chosen from the tables of each cycle alone, so that models outside the cycle do not change them:
+// edges whose columns are already nullable are preferred, then the table and the field sorting first.
+func (schema *Schema) deferCycles() {
+	tables := make([]*MainTable, 0, len(schema.Tables))
+	for _, table := range schema.Tables {
+		tables = append(tables, table)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Name < tables[j].Name
+	})
+
+	for cycle := findCycle(tables); cycle != nil; cycle = findCycle(tables) {
+		owner, dependency, edges := closingEdges(cycleComponent(tables, cycle[0]))
+		if edges == nil {
+			return
+		}
+
+		// list the cycle from the referenced table to the table owning the edges
+		path := append(dependencyPath(tables, dependency, owner), owner, dependency)
+		names := make([]string, 0, len(path))
+		for _, table := range path {
+			names = append(names, table.Type.Name())
+		}
+		for _, edge := range edges {
+			edge.deferred = true
+			schema.reportField(SeverityNote, owner, edge.Name, "",
+				"the foreign key is added after the tables are created and its columns are nullable, because it closes the reference cycle %s", strings.Join(names, " -> "))
+		}
+		schema.graphOutdated = true
+	}
+}
+
+// cycleComponent returns the tables in a reference cycle with table, that is, those depending on table and depended on by it,
+// in the order of tables.
+func cycleComponent(tables []*MainTable, table *MainTable) []*MainTable {
+	var component []*MainTable
+	for _, other := range tables {
+		if dependencyPath(tables, table, other) != nil && dependencyPath(tables, other, table) != nil {
+			component = append(component, other)
+		}
+	}
+	return component
+}
+
+// closingEdges returns the edges to defer in a set of tables in reference cycles with each other,
+// which are all the edges making owner depend on dependency, or nil if no edges between the tables can be deferred.
+func closingEdges(component []*MainTable) (*MainTable, *MainTable, []*Edge) {
+	var owner, dependency *MainTable
+	var edges []*Edge
+	better := func(table *MainTable, candidates []*Edge) bool {
+		if edges == nil {
+			return true
+		}
+		if nullable, ownNullable := allNullable(candidates), allNullable(edges); nullable != ownNullable {
+			return nullable
+		}
+		if table != owner {
+			return table.Name < owner.Name
+		}
+		return firstEdgeName(candidates) < firstEdgeName(edges)
+	}
+	for _, table := range component {
+		for _, peer := range component {
+			if !table.Depends(peer) {
+				continue
+			}
+			if candidates := deferrableEdges(table, peer); candidates != nil && better(table, candidates) {
+				owner, dependency, edges = table, peer, candidates
+			}
+		}
+	}
+	return owner, dependency, edges
+}
+
+func allNullable(edges []*Edge) bool {
+	for _, edge := range edges {
+		if !edge.nullable {
+			return false
+		}
+	}
+	return true
+}
+
+func firstEdgeName(edges []*Edge) string {
+	name := edges[0].Name
+	for _, edge := range edges[1:] {
+		if edge.Name < name {
+			name = edge.Name
+		}
+	}
+	return name
+}
+
+// dependencyPath returns the shortest list of tables from table to dependency, each depending on the next one,
+// excluding dependency, or nil if table does not depend on dependency. Ties are broken by the order of tables.
+func dependencyPath(tables []*MainTable, table *MainTable, dependency *MainTable) []*MainTable {
+	previous := map[*MainTable]*MainTable{table: nil}
+	queue := []*MainTable{table}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range tables {
+			if _, seen := previous[next]; seen && next != dependency || !current.Depends(next) {
+				continue
+			}
+			if next == dependency {
+				path := []*MainTable{current}
+				for step := previous[current]; step != nil; step = previous[step] {
+					path = append([]*MainTable{step}, path...)
+				}
+				return path
+			}
+			previous[next] = current
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
+
+// findCycle returns the tables of a reference cycle, each depending on the next one and the last one on the first one,
+// or nil if the tables can be sorted. Tables referencing themselves are not cycles, since they do not depend on themselves.
+func findCycle(tables []*MainTable) []*MainTable {
+	visited := map[*MainTable]bool{}
+	var path []*MainTable
+	var visit func(table *MainTable) []*MainTable
+	visit = func(table *MainTable) []*MainTable {
+		for i, ancestor := range path {
+			if ancestor == table {
+				return path[i:]
+			}
+		}
+		if visited[table] {
+			return nil
+		}
+		visited[table] = true
+		path = append(path, table)
+		for _, dependency := range tables {
+			if table.Depends(dependency) {
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return nil
+	}
+
+	for _, table := range tables {
+		if cycle := visit(table); cycle != nil {
+			return append([]*MainTable{}, cycle...)
+		}
+	}
+	return nil
+}
+
+// deferrableEdges returns the edges making table depend on dependency, if all of them are pointer fields whose foreign keys can be deferred.
+// It returns nil if table is a child of dependency.
+func deferrableEdges(table *MainTable, dependency *MainTable) []*Edge {
+	if table.knownParent == dependency {
+		return nil
+	}
+	var edges []*Edge
+	for _, edge := range table.Edges {
+		if edge.PeerTable != dependency.Name || edge.deferred || edge.secondary || edge.Type == EdgeTypeOneOne || edge.Type == EdgeTypeOneMulti {
+			continue
+		}
+		if edge.Type != EdgeTypeMultiOne && edge.Type != EdgeTypeMultiMulti {
+			return nil
+		}
+		edges = append(edges, edge)
+	}
+	return edges
+}
@@ -0,0 +1,125 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"strings"
+	"testing"
+)
+
+const cycleModels = `package models
+
+type Author struct {
+	Id       uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Featured *Book
+}
+
+type Book struct {
+	Id     uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Author *Author
+}
+`
+
+func TestDeferCycles(t *testing.T) {
+	_, _, models := sourceModels(t, cycleModels, "Author")
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+
+	author := schema.Tables["Author"]
+	if edge := author.FindEdgeByName("Featured"); edge == nil || !edge.deferred {
+		t.Fatalf("expected Author.Featured to close the cycle, got %+v", edge)
+	}
+	if edge := schema.Tables["Book"].FindEdgeByName("Author"); edge == nil || edge.deferred {
+		t.Errorf("expected only one edge of the cycle to be deferred, got %+v", edge)
+	}
+	if field := author.FindField("Featured_Id"); field == nil || !field.Nullable {
+		t.Errorf("expected the column closing the cycle to be nullable, got %+v", field)
+	}
+	if diagnostics := schema.Diagnostics(); len(diagnostics) != 1 || diagnostics[0].Severity != SeverityNote || diagnostics[0].Field != "Featured" ||
+		!strings.HasSuffix(diagnostics[0].Message, "Book -> Author -> Book") {
+		t.Errorf("expected a note about the deferred foreign key, got %v", diagnostics)
+	}
+
+	for name, test := range map[string]struct {
+		dialect  Dialect
+		expected string
+	}{
+		"mysql":    {MysqlDialect{}, "\nALTER TABLE `Author` ADD CONSTRAINT `fk_Author_Featured_Id` FOREIGN KEY (`Featured_Id`) REFERENCES `Book`(`Id`) ON UPDATE RESTRICT ON DELETE SET NULL;\n\n"},
+		"postgres": {PostgresDialect{}, "\nALTER TABLE \"Author\" ADD CONSTRAINT \"fk_Author_Featured_Id\" FOREIGN KEY (\"Featured_Id\") REFERENCES \"Book\"(\"Id\") ON UPDATE RESTRICT ON DELETE SET NULL DEFERRABLE INITIALLY DEFERRED;\n\n"},
+	} {
+		sql := outputSql(t, models, test.dialect)
+		if !strings.HasSuffix(sql, test.expected) {
+			t.Errorf("%s: expected the foreign key closing the cycle to be added last, got\n%s", name, sql)
+		}
+	}
+}
+
+// deferredEdges returns the deferred edges of the schema built from source, as Type.Field.
+func deferredEdges(t *testing.T, source string, names ...string) []string {
+	t.Helper()
+	_, _, models := sourceModels(t, source, names...)
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	var deferred []string
+	for _, table := range schema.getSortedTables() {
+		for _, edge := range table.Edges {
+			if edge.deferred {
+				deferred = append(deferred, table.Type.Name()+"."+edge.Name)
+			}
+		}
+	}
+	return deferred
+}
+
+func TestDeferCyclesIgnoresOtherModels(t *testing.T) {
+	// Anthology is visited before the tables of the cycle, and enters it through Book
+	const anthologyModel = `
+
+type Anthology struct {
+	Id   uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Best *Book
+}
+`
+	expected := deferredEdges(t, cycleModels, "Author")
+	if deferred := deferredEdges(t, cycleModels+anthologyModel, "Author", "Anthology"); !stringSlicesEqual(deferred, expected) {
+		t.Errorf("expected the deferred edges %v not to change with an unrelated model, got %v", expected, deferred)
+	}
+}
+
+func TestDeferCyclesPrefersNullableEdges(t *testing.T) {
+	const nullableModels = `package models
+
+type Author struct {
+	Id       uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Featured *Book
+}
+
+type Book struct {
+	Id     uint32  ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Author *Author ` + "`nullable:\"\"`" + `
+}
+`
+	if deferred := deferredEdges(t, nullableModels, "Author"); !stringSlicesEqual(deferred, []string{"Book.Author"}) {
+		t.Errorf("expected the nullable edge Book.Author to be deferred, got %v", deferred)
+	}
+}
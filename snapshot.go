@@ -0,0 +1,200 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SnapshotVersion is the version of the snapshot format written by Schema.Save.
+// It is incremented whenever the format changes, and LoadSchema upgrades the snapshots of the older versions:
+//
+//   - 1: the columns and keys of the tables, and the names, peers and types of the edges.
+//   - 2: adds the enum values, JSON paths, defaults, ON UPDATE clauses, comments and Go ordinals of the columns,
+//     the options of the tables, and whether edges are deferred, secondary or paired with an inverse.
+const SnapshotVersion = 2
+
+type schemaSnapshot struct {
+	Version int
+	Tables  []*tableSnapshot
+}
+
+type tableSnapshot struct {
+	Table       *Table
+	AuxTables   []*Table
+	Edges       []*edgeSnapshot
+	KnownParent string `json:",omitempty"`
+}
+
+type edgeSnapshot struct {
+	Name      string
+	PeerTable string
+	Type      string
+	Deferred  bool   `json:",omitempty"`
+	Inverse   string `json:",omitempty"`
+	Secondary bool   `json:",omitempty"`
+}
+
+// Save writes a snapshot of the schema that can be loaded again with LoadSchema.
+// Tables are written in dependency order so that the output is stable across runs.
+func (schema *Schema) Save(writer io.Writer) error {
+	snapshot := &schemaSnapshot{
+		Version: SnapshotVersion,
+		Tables:  make([]*tableSnapshot, 0, len(schema.Tables)),
+	}
+
+	for _, table := range schema.getSortedTables() {
+		entry := &tableSnapshot{
+			Table:     table.Table,
+			AuxTables: table.AuxTables,
+			Edges:     make([]*edgeSnapshot, 0, len(table.Edges)),
+		}
+		for _, edge := range table.Edges {
+			entry.Edges = append(entry.Edges, &edgeSnapshot{
+				Name:      edge.Name,
+				PeerTable: edge.PeerTable,
+				Type:      edge.Type.String(),
+				Deferred:  edge.deferred,
+				Inverse:   edge.inverse,
+				Secondary: edge.secondary,
+			})
+		}
+		if table.knownParent != nil {
+			entry.KnownParent = table.knownParent.Name
+		}
+		snapshot.Tables = append(snapshot.Tables, entry)
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(snapshot)
+}
+
+// LoadSchema reads a snapshot written by Schema.Save.
+//
+// The tables of the loaded schema have no Type, so the schema can be used as the Previous schema of a migration,
+// but it cannot be used to generate Go code.
+func LoadSchema(reader io.Reader) (*Schema, error) {
+	snapshot := &schemaSnapshot{}
+	if err := json.NewDecoder(reader).Decode(snapshot); err != nil {
+		return nil, err
+	}
+	if snapshot.Version < 1 || snapshot.Version > SnapshotVersion {
+		return nil, fmt.Errorf("unsupported schema snapshot version %d; this version of my-model reads versions 1 to %d", snapshot.Version, SnapshotVersion)
+	}
+	snapshot.upgrade()
+
+	schema := &Schema{
+		Tables:        make(map[string]*MainTable, len(snapshot.Tables)),
+		graphOutdated: true,
+	}
+
+	for _, entry := range snapshot.Tables {
+		if entry.Table == nil {
+			return nil, errors.New("schema snapshot contains a table without definition")
+		}
+		if _, exists := schema.Tables[entry.Table.Name]; exists {
+			return nil, fmt.Errorf("schema snapshot contains table %s twice", entry.Table.Name)
+		}
+
+		table := &MainTable{
+			Table:      entry.Table.normalized(),
+			AuxTables:  make([]*Table, 0, len(entry.AuxTables)),
+			Edges:      make([]*Edge, 0, len(entry.Edges)),
+			ValueLists: []*ValueList{},
+			yielded:    true,
+		}
+		for _, aux := range entry.AuxTables {
+			table.AuxTables = append(table.AuxTables, aux.normalized())
+		}
+		for _, edgeEntry := range entry.Edges {
+			edgeType, err := parseEdgeType(edgeEntry.Type)
+			if err != nil {
+				return nil, fmt.Errorf("%v in %s.%s", err, table.Name, edgeEntry.Name)
+			}
+			table.Edges = append(table.Edges, &Edge{
+				Name:      edgeEntry.Name,
+				PeerTable: edgeEntry.PeerTable,
+				Type:      edgeType,
+				deferred:  edgeEntry.Deferred,
+				inverse:   edgeEntry.Inverse,
+				secondary: edgeEntry.Secondary,
+			})
+		}
+		schema.Tables[table.Name] = table
+	}
+
+	for _, entry := range snapshot.Tables {
+		if entry.KnownParent == "" {
+			continue
+		}
+		parent, exists := schema.Tables[entry.KnownParent]
+		if !exists {
+			return nil, fmt.Errorf("parent %s of %s is not in the schema snapshot", entry.KnownParent, entry.Table.Name)
+		}
+		schema.Tables[entry.Table.Name].knownParent = parent
+	}
+	for _, table := range schema.Tables {
+		for _, edge := range table.Edges {
+			if _, exists := schema.Tables[edge.PeerTable]; !exists {
+				return nil, fmt.Errorf("peer %s of %s.%s is not in the schema snapshot", edge.PeerTable, table.Name, edge.Name)
+			}
+		}
+	}
+
+	return schema, nil
+}
+
+// upgrade converts a snapshot of an older version to the current one.
+func (snapshot *schemaSnapshot) upgrade() {
+	if snapshot.Version < 2 {
+		// The properties added in version 2 are omitted when empty, and the schemas of version 1 could not declare them,
+		// so the decoded snapshot is already equivalent to one written by version 2.
+		snapshot.Version = 2
+	}
+}
+
+// normalized replaces the collections omitted from a decoded table with empty ones, as NewTable does.
+func (table *Table) normalized() *Table {
+	if table.SimpleFields == nil {
+		table.SimpleFields = []*MysqlField{}
+	}
+	if table.PrimaryKeys == nil {
+		table.PrimaryKeys = []string{}
+	}
+	if table.UniqueKeys == nil {
+		table.UniqueKeys = map[string][]string{}
+	}
+	if table.CompositeKeys == nil {
+		table.CompositeKeys = map[string][]string{}
+	}
+	return table
+}
+
+func parseEdgeType(name string) (EdgeType, error) {
+	for edgeType := EdgeTypeMultiMulti; edgeType < EdgeTypeUnknownParent; edgeType++ {
+		if edgeType.String() == name {
+			return edgeType, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown edge type %q", name)
+}
@@ -0,0 +1,87 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	_, _, models := sourceModels(t, repositoryModels, "User")
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	saved := &strings.Builder{}
+	if err := schema.Save(saved); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	loaded, err := LoadSchema(strings.NewReader(saved.String()))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	again := &strings.Builder{}
+	if err := loaded.Save(again); err != nil {
+		t.Fatalf("save loaded schema: %v", err)
+	}
+	if again.String() != saved.String() {
+		t.Errorf("the loaded snapshot was saved as\n%s\ninstead of\n%s", again.String(), saved.String())
+	}
+
+	migration := &strings.Builder{}
+	if err := schema.OutputMigration(loaded, GeneratorConfig{Indent: "\t", Eol: "\n", SqlStream: migration}); err != nil {
+		t.Fatalf("output migration: %v", err)
+	}
+	if migration.String() != "" {
+		t.Errorf("expected no migration from the loaded snapshot, got\n%s", migration.String())
+	}
+}
+
+func TestLoadSchemaVersions(t *testing.T) {
+	const snapshot = `{
+	"Version": %d,
+	"Tables": [
+		{
+			"Table": {
+				"Name": "Account",
+				"SimpleFields": [{"Name": "Id", "Type": "INT UNSIGNED", "Nullable": false, "AutoIncrement": true}],
+				"PrimaryKeys": ["Id"]
+			},
+			"AuxTables": [],
+			"Edges": []
+		}
+	]
+}`
+	for version := 1; version <= SnapshotVersion; version++ {
+		schema, err := LoadSchema(strings.NewReader(fmt.Sprintf(snapshot, version)))
+		if err != nil {
+			t.Errorf("load version %d: %v", version, err)
+		} else if table := schema.Tables["Account"]; table == nil || table.FindField("Id") == nil {
+			t.Errorf("the table of version %d was not loaded", version)
+		}
+	}
+	for _, version := range []int{0, SnapshotVersion + 1} {
+		if _, err := LoadSchema(strings.NewReader(fmt.Sprintf(snapshot, version))); err == nil {
+			t.Errorf("expected version %d to be rejected", version)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SourcePackage indexes the syntax trees of a type-checked package, so that its types can be wrapped with Type
+// without walking the files again for every model.
+type SourcePackage struct {
+	fset    *token.FileSet
+	docs    map[token.Pos]string        // doc comments of struct fields by the position of their names
+	methods map[token.Pos]*ast.FuncDecl // method declarations by the position of their names
+}
+
+// NewSourcePackage indexes the doc comments of fields and the method declarations in files.
+// fset is used to report the positions of fields in diagnostics and may be nil.
+// files are the syntax trees of the package, from which the doc comments of fields are read as column comments.
+func NewSourcePackage(fset *token.FileSet, files ...*ast.File) *SourcePackage {
+	pkg := &SourcePackage{fset: fset, docs: map[token.Pos]string{}, methods: map[token.Pos]*ast.FuncDecl{}}
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.Field:
+				if node.Doc != nil {
+					for _, name := range node.Names {
+						pkg.docs[name.Pos()] = strings.Join(strings.Fields(node.Doc.Text()), " ")
+					}
+				}
+			case *ast.FuncDecl:
+				if node.Recv != nil {
+					pkg.methods[node.Name.Pos()] = node
+				}
+			}
+			return true
+		})
+	}
+	return pkg
+}
+
+// Type wraps a type of the package, so that a schema can be built without compiling the models.
+func (pkg *SourcePackage) Type(typ types.Type) TypeInfo {
+	return sourceType{typ, pkg}
+}
+
+// SourceType wraps a type from type-checked source code, so that a schema can be built without compiling the models.
+// The files are indexed on every call; use SourcePackage to wrap several types of the same package.
+func SourceType(fset *token.FileSet, typ types.Type, files ...*ast.File) TypeInfo {
+	return NewSourcePackage(fset, files...).Type(typ)
+}
+
+type sourceType struct {
+	typ types.Type
+	pkg *SourcePackage
+}
+
+func (typ sourceType) Name() string {
+	switch t := typ.typ.(type) {
+	case *types.Named:
+		return t.Obj().Name()
+	case *types.Basic:
+		return t.Name()
+	}
+	return ""
+}
+
+func (typ sourceType) PkgPath() string {
+	if named, ok := typ.typ.(*types.Named); ok && named.Obj().Pkg() != nil {
+		return named.Obj().Pkg().Path()
+	}
+	return ""
+}
+
+func (typ sourceType) String() string {
+	return types.TypeString(typ.typ, func(pkg *types.Package) string {
+		return pkg.Name()
+	})
+}
+
+func (typ sourceType) Kind() reflect.Kind {
+	switch t := typ.typ.Underlying().(type) {
+	case *types.Basic:
+		switch t.Kind() {
+		case types.Bool:
+			return reflect.Bool
+		case types.Int:
+			return reflect.Int
+		case types.Int8:
+			return reflect.Int8
+		case types.Int16:
+			return reflect.Int16
+		case types.Int32:
+			return reflect.Int32
+		case types.Int64:
+			return reflect.Int64
+		case types.Uint:
+			return reflect.Uint
+		case types.Uint8:
+			return reflect.Uint8
+		case types.Uint16:
+			return reflect.Uint16
+		case types.Uint32:
+			return reflect.Uint32
+		case types.Uint64:
+			return reflect.Uint64
+		case types.Uintptr:
+			return reflect.Uintptr
+		case types.Float32:
+			return reflect.Float32
+		case types.Float64:
+			return reflect.Float64
+		case types.Complex64:
+			return reflect.Complex64
+		case types.Complex128:
+			return reflect.Complex128
+		case types.String:
+			return reflect.String
+		case types.UnsafePointer:
+			return reflect.UnsafePointer
+		}
+	case *types.Pointer:
+		return reflect.Ptr
+	case *types.Slice:
+		return reflect.Slice
+	case *types.Array:
+		return reflect.Array
+	case *types.Map:
+		return reflect.Map
+	case *types.Chan:
+		return reflect.Chan
+	case *types.Signature:
+		return reflect.Func
+	case *types.Interface:
+		return reflect.Interface
+	case *types.Struct:
+		return reflect.Struct
+	}
+	return reflect.Invalid
+}
+
+func (typ sourceType) Elem() TypeInfo {
+	switch t := typ.typ.Underlying().(type) {
+	case *types.Pointer:
+		return sourceType{t.Elem(), typ.pkg}
+	case *types.Slice:
+		return sourceType{t.Elem(), typ.pkg}
+	case *types.Array:
+		return sourceType{t.Elem(), typ.pkg}
+	case *types.Map:
+		return sourceType{t.Elem(), typ.pkg}
+	case *types.Chan:
+		return sourceType{t.Elem(), typ.pkg}
+	}
+	panic("Elem of invalid type " + typ.String())
+}
+
+func (typ sourceType) Key() TypeInfo {
+	if mapType, ok := typ.typ.Underlying().(*types.Map); ok {
+		return sourceType{mapType.Key(), typ.pkg}
+	}
+	panic("Key of non-map type " + typ.String())
+}
+
+func (typ sourceType) Len() int {
+	if array, ok := typ.typ.Underlying().(*types.Array); ok {
+		return int(array.Len())
+	}
+	panic("Len of non-array type " + typ.String())
+}
+
+func (typ sourceType) NumField() int {
+	return typ.structType().NumFields()
+}
+
+func (typ sourceType) Field(i int) FieldInfo {
+	structType := typ.structType()
+	field := structType.Field(i)
+	info := FieldInfo{
+		Name:      field.Name(),
+		Type:      sourceType{field.Type(), typ.pkg},
+		Tag:       reflect.StructTag(structType.Tag(i)),
+		Anonymous: field.Embedded(),
+		Doc:       typ.pkg.docs[field.Pos()],
+	}
+	if typ.pkg.fset != nil && field.Pos().IsValid() {
+		info.Position = typ.pkg.fset.Position(field.Pos()).String()
+	}
+	return info
+}
+
+func (typ sourceType) structType() *types.Struct {
+	if structType, ok := typ.typ.Underlying().(*types.Struct); ok {
+		return structType
+	}
+	panic("type " + typ.String() + " is not a struct")
+}
+
+// EnumValues returns the values of the typed string constants of a named type, in declaration order.
+func (typ sourceType) EnumValues() []string {
+	named, ok := typ.typ.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || typ.Kind() != reflect.String {
+		return nil
+	}
+	constants := []*types.Const{}
+	scope := named.Obj().Pkg().Scope()
+	for _, name := range scope.Names() {
+		if object, isConst := scope.Lookup(name).(*types.Const); isConst && types.Identical(object.Type(), named) {
+			constants = append(constants, object)
+		}
+	}
+	sort.Slice(constants, func(i, j int) bool {
+		return constants[i].Pos() < constants[j].Pos()
+	})
+
+	values := []string{}
+	seen := map[string]bool{}
+	for _, object := range constants {
+		if value := constant.StringVal(object.Val()); !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+func (typ sourceType) HasMethod(name string) bool {
+	object, _, _ := types.LookupFieldOrMethod(types.NewPointer(typ.typ), true, nil, name)
+	_, isMethod := object.(*types.Func)
+	return isMethod
+}
+
+// tableOptions reads the options returned by the TableOptions method of typ from its declaration,
+// which must consist of a single return of a composite literal with string literals.
+func (typ sourceType) tableOptions() (TableOptions, error) {
+	object, _, _ := types.LookupFieldOrMethod(types.NewPointer(typ.typ), true, nil, "TableOptions")
+	decl := typ.pkg.methods[object.Pos()]
+	if decl == nil || decl.Body == nil || len(decl.Body.List) != 1 {
+		return TableOptions{}, fmt.Errorf("the TableOptions method of %s must consist of a single return statement to be read from source code", typ)
+	}
+	returned, ok := decl.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(returned.Results) != 1 {
+		return TableOptions{}, fmt.Errorf("the TableOptions method of %s must consist of a single return statement to be read from source code", typ)
+	}
+	literal, ok := returned.Results[0].(*ast.CompositeLit)
+	if !ok {
+		return TableOptions{}, fmt.Errorf("the TableOptions method of %s must return a composite literal to be read from source code", typ)
+	}
+
+	options := TableOptions{}
+	fields := map[string]*string{
+		"Engine":    &options.Engine,
+		"Charset":   &options.Charset,
+		"Collate":   &options.Collate,
+		"RowFormat": &options.RowFormat,
+		"Comment":   &options.Comment,
+	}
+	for _, element := range literal.Elts {
+		pair, ok := element.(*ast.KeyValueExpr)
+		if !ok {
+			return TableOptions{}, fmt.Errorf("the options returned by the TableOptions method of %s must be keyed to be read from source code", typ)
+		}
+		key, ok := pair.Key.(*ast.Ident)
+		if !ok || fields[key.Name] == nil {
+			return TableOptions{}, fmt.Errorf("the TableOptions method of %s returns an unknown option", typ)
+		}
+		value, ok := pair.Value.(*ast.BasicLit)
+		if !ok || value.Kind != token.STRING {
+			return TableOptions{}, fmt.Errorf("the %s option returned by the TableOptions method of %s must be a string literal to be read from source code", key.Name, typ)
+		}
+		*fields[key.Name], _ = strconv.Unquote(value.Value)
+	}
+	return options, nil
+}
@@ -0,0 +1,53 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSourceType(t *testing.T) {
+	_, _, models := sourceModels(t, repositoryModels, "User")
+	user := models[0]
+	if user.Name() != "User" || user.PkgPath() != "example.com/models" || user.Kind() != reflect.Struct {
+		t.Errorf("unexpected type %s.%s of kind %v", user.PkgPath(), user.Name(), user.Kind())
+	}
+
+	fields := map[string]FieldInfo{}
+	for i := 0; i < user.NumField(); i++ {
+		fields[user.Field(i).Name] = user.Field(i)
+	}
+	if name := fields["Name"]; name.Doc != "Name is the login name." || name.Position != "models.go:9:2" || name.Tag.Get("width") != "64" {
+		t.Errorf("unexpected field %+v", name)
+	}
+	if email := fields["Email"].Type; email.Kind() != reflect.Ptr || email.Elem().Kind() != reflect.String {
+		t.Errorf("unexpected type %s of Email", email)
+	}
+	if status := fields["Status"].Type; !reflect.DeepEqual(status.EnumValues(), []string{"active", "banned"}) {
+		t.Errorf("expected the constants of Status as its values, got %q", status.EnumValues())
+	}
+	if level := fields["Level"].Type; level.Kind() != reflect.Uint8 || level.EnumValues() != nil {
+		t.Errorf("expected Priority to be an integer without constants, got %v %q", level.Kind(), level.EnumValues())
+	}
+	if !strings.HasSuffix(fields["Created"].Type.String(), "time.Time") || fields["Posts"].Type.Elem().Name() != "Post" {
+		t.Errorf("unexpected types %s and %s", fields["Created"].Type, fields["Posts"].Type)
+	}
+}
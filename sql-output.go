@@ -19,7 +19,9 @@
 package myModel
 
 import (
+	"fmt"
 	"github.com/hoop33/go-elvis"
+	"sort"
 	"strings"
 )
 
@@ -30,6 +32,23 @@ func (schema *Schema) OutputSql(config GeneratorConfig) error {
 		}
 	}
 
+	statements := []string{}
+	for _, table := range schema.getAllTables() {
+		for _, foreign := range table.ForeignKeys {
+			if statement := config.dialect().AddForeignKey(table, foreign); foreign.Deferred && statement != "" {
+				statements = append(statements, statement)
+			}
+		}
+	}
+	for _, statement := range statements {
+		if err := config.WriteSqlF("%s%s", statement, config.Eol); err != nil {
+			return err
+		}
+	}
+	if len(statements) > 0 {
+		return config.WriteSqlReturnIndent(0)
+	}
+
 	return nil
 }
 
@@ -50,12 +69,12 @@ func (schema *Schema) outputSqlMainTable(table *MainTable, config GeneratorConfi
 }
 
 func (schema *Schema) outputSqlTable(table *Table, config GeneratorConfig) error {
-	if err := config.WriteSqlF("CREATE TABLE %s (", table.Name); err != nil {
+	if err := config.WriteSqlF("CREATE TABLE %s (", config.dialect().QuoteIdentifier(table.Name)); err != nil {
 		return err
 	}
 	first := true
 
-	fieldLines := indentMysqlFields(table.SimpleFields)
+	fieldLines := indentMysqlFields(table.SimpleFields, config.dialect())
 	for _, field := range fieldLines {
 		if !first {
 			if err := config.WriteSqlF(","); err != nil {
@@ -71,78 +90,110 @@ func (schema *Schema) outputSqlTable(table *Table, config GeneratorConfig) error
 		}
 	}
 
-	if len(table.PrimaryKeys) > 0 {
+	dialect := config.dialect()
+	if primaryKey := dialect.PrimaryKey(table); len(table.PrimaryKeys) > 0 && primaryKey != "" {
 		if err := config.WriteSql(","); err != nil {
 			return err
 		}
 		if err := config.WriteSqlReturnIndent(1); err != nil {
 			return err
 		}
-		if err := config.WriteSqlF("PRIMARY KEY (%s)", strings.Join(table.PrimaryKeys, ", ")); err != nil {
+		if err := config.WriteSql(primaryKey); err != nil {
 			return err
 		}
 	}
-	for indexName, colNames := range table.UniqueKeys {
+	for _, indexName := range sortedKeyNames(table.UniqueKeys) {
 		if err := config.WriteSql(","); err != nil {
 			return err
 		}
 		if err := config.WriteSqlReturnIndent(1); err != nil {
 			return err
 		}
-		if err := config.WriteSqlF("UNIQUE KEY `%s` (%s)", indexName, strings.Join(colNames, ", ")); err != nil {
+		if err := config.WriteSql(dialect.UniqueKey(table, indexName, table.UniqueKeys[indexName])); err != nil {
 			return err
 		}
 	}
-	for indexName, colNames := range table.CompositeKeys {
+	statements := []string{}
+	for _, indexName := range sortedKeyNames(table.CompositeKeys) {
+		declaration, inline := dialect.CompositeKey(table, indexName, table.CompositeKeys[indexName])
+		if !inline {
+			statements = append(statements, declaration)
+			continue
+		}
 		if err := config.WriteSql(","); err != nil {
 			return err
 		}
 		if err := config.WriteSqlReturnIndent(1); err != nil {
 			return err
 		}
-		if err := config.WriteSqlF("KEY `%s` (%s)", indexName, strings.Join(colNames, ", ")); err != nil {
+		if err := config.WriteSql(declaration); err != nil {
 			return err
 		}
 	}
 	for _, foreign := range table.ForeignKeys {
+		if foreign.Deferred && dialect.AddForeignKey(table, foreign) != "" {
+			continue // added by OutputSql after all tables are created
+		}
 		if err := config.WriteSql(","); err != nil {
 			return err
 		}
 		if err := config.WriteSqlReturnIndent(1); err != nil {
 			return err
 		}
-		if err := config.WriteSqlF("FOREIGN KEY (%s) REFERENCES %s(%s) ON UPDATE %s ON DELETE %s",
-			strings.Join(foreign.SourceColumns, ", "), foreign.RefTable, strings.Join(foreign.RefColumns, ", "),
-			foreign.OnUpdate, foreign.OnDelete,
-		); err != nil {
+		if err := config.WriteSql(foreignKeyClause(table, foreign, dialect)); err != nil {
 			return err
 		}
 	}
 
-	if err := config.WriteSqlF("%s);%s", config.Eol, config.Eol); err != nil {
+	options, optionStatements := dialect.TableOptions(table, config.tableOptions(table))
+	statements = append(statements, optionStatements...)
+	if err := config.WriteSqlF("%s)%s;%s", config.Eol, elvis.Ternary(options != "", " "+options, "").(string), config.Eol); err != nil {
 		return err
 	}
+	for _, statement := range statements {
+		if err := config.WriteSqlF("%s%s", statement, config.Eol); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func indentMysqlFields(fields []*MysqlField) []string {
-	const MysqlFieldLength = 4
+func primaryKeyClause(dialect Dialect, columns []string) string {
+	return "PRIMARY KEY (" + quoteIdentifiers(dialect, columns) + ")"
+}
+
+func foreignKeyClause(table *Table, foreign ForeignKey, dialect Dialect) string {
+	return fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(%s) ON UPDATE %s ON DELETE %s",
+		dialect.QuoteIdentifier(table.ForeignKeyName(foreign)),
+		quoteIdentifiers(dialect, foreign.SourceColumns), dialect.QuoteIdentifier(foreign.RefTable), quoteIdentifiers(dialect, foreign.RefColumns),
+		foreign.OnUpdate, foreign.OnDelete,
+	)
+}
 
-	lines := make([][MysqlFieldLength]string, 0, len(fields))
+// sortedKeyNames returns the index names of keys in a stable order.
+func sortedKeyNames(keys map[string][]string) []string {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func indentMysqlFields(fields []*MysqlField, dialect Dialect) []string {
+	lines := make([][]string, 0, len(fields))
 	for _, field := range fields {
-		lines = append(lines, [...]string{
-			field.Name,
-			field.Type,
-			elvis.Ternary(field.Nullable, "", "NOT NULL").(string),
-			elvis.Ternary(field.AutoIncrement, "AUTO_INCREMENT", "").(string),
-		})
+		lines = append(lines, append([]string{dialect.QuoteIdentifier(field.Name)}, dialect.ColumnDefinition(field)...))
 	}
 
-	lengths := [MysqlFieldLength]int{}
+	lengths := []int{}
 	for _, line := range lines {
-		for i := range lengths {
-			if lengths[i] < len(line[i]) {
-				lengths[i] = len(line[i])
+		for i, piece := range line {
+			if i == len(lengths) {
+				lengths = append(lengths, 0)
+			}
+			if lengths[i] < len(piece) {
+				lengths[i] = len(piece)
 			}
 		}
 	}
@@ -151,6 +202,9 @@ func indentMysqlFields(fields []*MysqlField) []string {
 	for _, line := range lines {
 		retLine := ""
 		for i, piece := range line {
+			if lengths[i] == 0 {
+				continue // a clause that none of the columns have
+			}
 			retLine += piece
 			retLine += strings.Repeat(" ", lengths[i]-len(piece)+1)
 		}
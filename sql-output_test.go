@@ -0,0 +1,73 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const ddlModels = `package models
+
+type Account struct {
+	_     struct{} ` + "`comment:\"accounts\"`" + `
+	Id    uint32   ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Name  string   ` + "`width:\"32\" unique:\"name\"`" + `
+	Score *int64
+	Tags  []string ` + "`width:\"16\"`" + `
+	Team  *Team
+}
+
+type Team struct {
+	Id    uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Title string ` + "`width:\"64\" composite:\"title\"`" + `
+}
+`
+
+// outputSql returns the CREATE TABLE statements for the models in dialect.
+func outputSql(t *testing.T, models []TypeInfo, dialect Dialect) string {
+	t.Helper()
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	sql := &strings.Builder{}
+	if err := schema.OutputSql(GeneratorConfig{Indent: "\t", Eol: "\n", SqlStream: sql, Dialect: dialect}); err != nil {
+		t.Fatalf("output SQL: %v", err)
+	}
+	return sql.String()
+}
+
+// TestOutputSql compares the CREATE TABLE statements in each dialect with testdata/account.<dialect>.sql.
+func TestOutputSql(t *testing.T) {
+	_, _, models := sourceModels(t, ddlModels, "Account")
+	for name, dialect := range map[string]Dialect{"mysql": MysqlDialect{}, "postgres": PostgresDialect{}, "sqlite": SqliteDialect{}} {
+		t.Run(name, func(t *testing.T) {
+			expected, err := ioutil.ReadFile(filepath.Join("testdata", "account."+name+".sql"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql := outputSql(t, models, dialect); sql != string(expected) {
+				t.Errorf("expected\n%s\ngot\n%s", expected, sql)
+			}
+		})
+	}
+}
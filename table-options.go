@@ -0,0 +1,157 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// TableOptions are the options of a table following its definitions in CREATE TABLE.
+// Empty options are left to the defaults of the database.
+//
+// A model declares its options with the tags engine, charset, collate, rowFormat and comment
+// on a blank marker field such as
+//
+//	_ struct{} `engine:"InnoDB" comment:"registered users"`
+//
+// or with a TableOptions method on the model type.
+// When the models are loaded from source code, the method is read from its declaration instead of being called,
+// so it must return a composite literal of string literals; otherwise it is ignored with a warning.
+// Auxiliary tables have the options of their model, except the comment.
+type TableOptions struct {
+	Engine    string `json:",omitempty"` // the storage engine, such as InnoDB
+	Charset   string `json:",omitempty"` // the default character set, such as utf8mb4
+	Collate   string `json:",omitempty"` // the default collation, such as utf8mb4_unicode_ci
+	RowFormat string `json:",omitempty"` // the row format, such as DYNAMIC or COMPRESSED
+	Comment   string `json:",omitempty"`
+}
+
+// tableOptionsModel is implemented by models declaring their options with a method.
+type tableOptionsModel interface {
+	TableOptions() TableOptions
+}
+
+var tableOptionPattern = regexp.MustCompile(`^\w+$`)
+
+const (
+	maxTableCommentLength  = 2048
+	maxColumnCommentLength = 1024
+)
+
+// override returns options with the non-empty options in overrides replacing them.
+func (options TableOptions) override(overrides TableOptions) TableOptions {
+	for _, option := range []struct{ value, override *string }{
+		{&options.Engine, &overrides.Engine},
+		{&options.Charset, &overrides.Charset},
+		{&options.Collate, &overrides.Collate},
+		{&options.RowFormat, &overrides.RowFormat},
+		{&options.Comment, &overrides.Comment},
+	} {
+		if *option.override != "" {
+			*option.value = *option.override
+		}
+	}
+	return options
+}
+
+// check returns an error if an option cannot be written to CREATE TABLE as is.
+func (options TableOptions) check() error {
+	for _, option := range []struct{ name, value string }{
+		{"engine", options.Engine},
+		{"charset", options.Charset},
+		{"collate", options.Collate},
+		{"rowFormat", options.RowFormat},
+	} {
+		if option.value != "" && !tableOptionPattern.MatchString(option.value) {
+			return fmt.Errorf("invalid %s %q", option.name, option.value)
+		}
+	}
+	if utf8.RuneCountInString(options.Comment) > maxTableCommentLength {
+		return fmt.Errorf("table comments cannot be longer than %d characters", maxTableCommentLength)
+	}
+	return nil
+}
+
+// tagTableOptions returns the options declared by the tags of a marker field.
+func tagTableOptions(tag reflect.StructTag) TableOptions {
+	options := TableOptions{}
+	options.Engine, _ = tag.Lookup("engine")
+	options.Charset, _ = tag.Lookup("charset")
+	options.Collate, _ = tag.Lookup("collate")
+	options.RowFormat, _ = tag.Lookup("rowFormat")
+	options.Comment, _ = tag.Lookup("comment")
+	options.RowFormat = strings.ToUpper(options.RowFormat)
+	return options
+}
+
+// methodTableOptions returns the options returned by the TableOptions method of typ.
+// The method of a type loaded from source code is not called but read from its declaration.
+func methodTableOptions(typ TypeInfo) (TableOptions, error) {
+	if source, ok := typ.(sourceType); ok {
+		return source.tableOptions()
+	}
+	reflected, ok := typ.(reflectType)
+	if !ok {
+		return TableOptions{}, fmt.Errorf("the TableOptions method of %s cannot be called", typ)
+	}
+	model, ok := reflect.New(reflected.Type).Interface().(tableOptionsModel)
+	if !ok {
+		return TableOptions{}, errors.New("the TableOptions method must have the signature TableOptions() myModel.TableOptions")
+	}
+	return model.TableOptions(), nil
+}
+
+// columnComment returns the comment of the column declared by field, from its comment tag or its doc comment.
+func columnComment(field FieldInfo) string {
+	if comment, exists := field.Tag.Lookup("comment"); exists {
+		return comment
+	}
+	return field.Doc
+}
+
+// tableOptions returns the options of table, with the options of the config for the options the model leaves empty.
+func (config *GeneratorConfig) tableOptions(table *Table) TableOptions {
+	return config.TableOptions.override(table.Options)
+}
+
+// diffTableOptions returns the ALTER TABLE clause changing the options from oldOptions to newOptions, if any.
+// An option removed from the models is kept, since the default of the database cannot be restored, except the comment.
+func diffTableOptions(oldOptions TableOptions, newOptions TableOptions) string {
+	changed := TableOptions{}
+	for _, option := range []struct{ old, new, changed *string }{
+		{&oldOptions.Engine, &newOptions.Engine, &changed.Engine},
+		{&oldOptions.Charset, &newOptions.Charset, &changed.Charset},
+		{&oldOptions.Collate, &newOptions.Collate, &changed.Collate},
+		{&oldOptions.RowFormat, &newOptions.RowFormat, &changed.RowFormat},
+	} {
+		if *option.new != *option.old {
+			*option.changed = *option.new
+		}
+	}
+	clause := mysqlTableOptions(changed)
+	if newOptions.Comment != oldOptions.Comment {
+		clause = strings.TrimSpace(clause + " COMMENT=" + quoteSqlString(newOptions.Comment))
+	}
+	return clause
+}
@@ -0,0 +1,78 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"testing"
+)
+
+// optionModels declares the TableOptions methods locally, since the importer of the tests cannot load this package.
+const optionModels = `package models
+
+type TableOptions struct {
+	Engine  string
+	Comment string
+}
+
+type Literal struct {
+	Id uint32 ` + "`primaryKey:\"\"`" + `
+}
+
+func (Literal) TableOptions() TableOptions {
+	return TableOptions{Engine: "MyISAM", Comment: "literal"}
+}
+
+const engine = "MyISAM"
+
+type Computed struct {
+	Id uint32 ` + "`primaryKey:\"\"`" + `
+}
+
+func (Computed) TableOptions() TableOptions {
+	return TableOptions{Engine: engine}
+}
+`
+
+func TestSourceTableOptions(t *testing.T) {
+	_, _, models := sourceModels(t, optionModels, "Literal", "Computed")
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	if options := schema.Tables["Literal"].Options; options != (TableOptions{Engine: "MyISAM", Comment: "literal"}) {
+		t.Errorf("expected the options of Literal to be read from its method, got %+v", options)
+	}
+	if options := schema.Tables["Computed"].Options; options != (TableOptions{}) {
+		t.Errorf("expected the method of Computed to be ignored, got %+v", options)
+	}
+	if diagnostics := schema.Diagnostics(); len(diagnostics) != 1 || diagnostics[0].Severity != SeverityWarning || diagnostics[0].Type != "Computed" {
+		t.Errorf("expected a warning about the method of Computed, got %v", diagnostics)
+	}
+}
+
+func TestSourceColumnComments(t *testing.T) {
+	_, _, models := sourceModels(t, repositoryModels, "User")
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	if comment := schema.Tables["User"].FindField("Name").Comment; comment != "Name is the login name." {
+		t.Errorf("expected the comment of Name to be its doc comment, got %q", comment)
+	}
+}
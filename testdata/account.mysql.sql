@@ -0,0 +1,22 @@
+CREATE TABLE `Team` (
+	`Id`    INT UNSIGNED NOT NULL AUTO_INCREMENT,
+	`Title` VARCHAR(64)  NOT NULL,
+	PRIMARY KEY (`Id`),
+	KEY `title` (`Title`)
+);
+
+CREATE TABLE `Account` (
+	`Id`      INT UNSIGNED  NOT NULL AUTO_INCREMENT,
+	`Name`    VARCHAR(32)   NOT NULL,
+	`Score`   BIGINT SIGNED,
+	`Team_Id` INT UNSIGNED  NOT NULL,
+	PRIMARY KEY (`Id`),
+	UNIQUE KEY `name` (`Name`),
+	CONSTRAINT `fk_Account_Team_Id` FOREIGN KEY (`Team_Id`) REFERENCES `Team`(`Id`) ON UPDATE RESTRICT ON DELETE RESTRICT
+) COMMENT='accounts';
+CREATE TABLE `Account_Tags` (
+	`Account_Id` INT UNSIGNED NOT NULL,
+	`Value`      VARCHAR(16)  NOT NULL,
+	CONSTRAINT `fk_Account_Tags_Account_Id` FOREIGN KEY (`Account_Id`) REFERENCES `Account`(`Id`) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
@@ -0,0 +1,23 @@
+CREATE TABLE "Team" (
+	"Id"    BIGINT      NOT NULL GENERATED BY DEFAULT AS IDENTITY,
+	"Title" VARCHAR(64) NOT NULL,
+	PRIMARY KEY ("Id")
+);
+CREATE INDEX "Team_title" ON "Team" ("Title");
+
+CREATE TABLE "Account" (
+	"Id"      BIGINT      NOT NULL GENERATED BY DEFAULT AS IDENTITY,
+	"Name"    VARCHAR(32) NOT NULL,
+	"Score"   BIGINT,
+	"Team_Id" BIGINT      NOT NULL,
+	PRIMARY KEY ("Id"),
+	CONSTRAINT "Account_name" UNIQUE ("Name"),
+	CONSTRAINT "fk_Account_Team_Id" FOREIGN KEY ("Team_Id") REFERENCES "Team"("Id") ON UPDATE RESTRICT ON DELETE RESTRICT
+);
+COMMENT ON TABLE "Account" IS 'accounts';
+CREATE TABLE "Account_Tags" (
+	"Account_Id" BIGINT      NOT NULL,
+	"Value"      VARCHAR(16) NOT NULL,
+	CONSTRAINT "fk_Account_Tags_Account_Id" FOREIGN KEY ("Account_Id") REFERENCES "Account"("Id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+
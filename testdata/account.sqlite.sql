@@ -0,0 +1,20 @@
+CREATE TABLE "Team" (
+	"Id"    INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"Title" TEXT    NOT NULL
+);
+CREATE INDEX "Team_title" ON "Team" ("Title");
+
+CREATE TABLE "Account" (
+	"Id"      INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"Name"    TEXT    NOT NULL,
+	"Score"   INTEGER,
+	"Team_Id" INTEGER NOT NULL,
+	CONSTRAINT "Account_name" UNIQUE ("Name"),
+	CONSTRAINT "fk_Account_Team_Id" FOREIGN KEY ("Team_Id") REFERENCES "Team"("Id") ON UPDATE RESTRICT ON DELETE RESTRICT
+);
+CREATE TABLE "Account_Tags" (
+	"Account_Id" INTEGER NOT NULL,
+	"Value"      TEXT    NOT NULL,
+	CONSTRAINT "fk_Account_Tags_Account_Id" FOREIGN KEY ("Account_Id") REFERENCES "Account"("Id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+
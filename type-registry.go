@@ -0,0 +1,93 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"reflect"
+	"sync"
+)
+
+// TypeMapping describes how the values of a Go type are stored in a column.
+//
+// The expressions are Go code in the package of the generated repositories, in which value is the value to convert.
+// For example, time.Duration can be stored in milliseconds with
+//
+//	TypeMapping{MysqlType: "BIGINT", Encode: "value.Milliseconds()", Decode: "time.Duration(value) * time.Millisecond", ScanType: "int64", Imports: []string{"time"}}
+type TypeMapping struct {
+	MysqlType string // the type of the column, such as "BINARY(16)" or "BIGINT UNSIGNED"
+	Nullable  bool   // whether the column is nullable even if the field is not a pointer, because Encode returns nil for some values
+
+	Encode      string   // converts value of the Go type to a value accepted by the driver; empty if the driver accepts the Go type
+	Decode      string   // converts value of ScanType to the Go type; empty if the Go type can be scanned directly
+	DecodeError bool     // whether Decode returns an error along with the converted value
+	ScanType    string   // the type the column is scanned into before Decode
+	Imports     []string // import paths referred to by the expressions and ScanType
+}
+
+// TypeRegistry maps Go types to columns, for types that are neither mapped by their kind nor declared with tags.
+//
+// Types are identified by their package path and name, so the same registry applies to reflect types and to source types.
+// The mappings in GeneratorConfig.Types take precedence over those registered with RegisterColumnType and the built-in mappings.
+type TypeRegistry struct {
+	lock     sync.RWMutex
+	mappings map[string]*TypeMapping
+}
+
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{mappings: map[string]*TypeMapping{}}
+}
+
+// defaultTypes contains the built-in mappings and those registered with RegisterColumnType.
+var defaultTypes = func() *TypeRegistry {
+	registry := NewTypeRegistry()
+	registry.Register("time", "Time", TypeMapping{MysqlType: "TIMESTAMP"})
+	return registry
+}()
+
+// Register declares the mapping of the type name in the package pkgPath.
+func (registry *TypeRegistry) Register(pkgPath string, name string, mapping TypeMapping) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	registry.mappings[pkgPath+"."+name] = &mapping
+}
+
+// RegisterType declares the mapping of a named type.
+func (registry *TypeRegistry) RegisterType(typ reflect.Type, mapping TypeMapping) {
+	registry.Register(typ.PkgPath(), typ.Name(), mapping)
+}
+
+// lookup returns the mapping of typ in registry, which may be nil, or in defaultTypes.
+func (registry *TypeRegistry) lookup(typ TypeInfo) *TypeMapping {
+	if typ.Name() == "" {
+		return nil
+	}
+	key := typ.PkgPath() + "." + typ.Name()
+	for _, candidate := range [...]*TypeRegistry{registry, defaultTypes} {
+		if candidate == nil {
+			continue
+		}
+		candidate.lock.RLock()
+		mapping, exists := candidate.mappings[key]
+		candidate.lock.RUnlock()
+		if exists {
+			return mapping
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const registryModels = `package models
+
+import "time"
+
+type Job struct {
+	Id      uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Timeout time.Duration
+	Retry   *time.Duration
+	Delays  []time.Duration
+}
+`
+
+func durationRegistry() *TypeRegistry {
+	registry := NewTypeRegistry()
+	registry.RegisterType(reflect.TypeOf(time.Duration(0)), TypeMapping{
+		MysqlType: "DOUBLE",
+		Encode:    "value.Seconds()",
+		Decode:    "time.Duration(value * float64(time.Second))",
+		ScanType:  "float64",
+		Imports:   []string{"time"},
+	})
+	return registry
+}
+
+func TestTypeRegistry(t *testing.T) {
+	registry := durationRegistry()
+	for _, test := range []struct {
+		registry *TypeRegistry
+		value    interface{}
+		expected string
+	}{
+		{registry, time.Duration(0), "DOUBLE"},
+		{nil, time.Duration(0), "BIGINT SIGNED"}, // mapped by its kind without the registry
+		{registry, time.Time{}, "TIMESTAMP"},     // the built-in mappings apply too
+	} {
+		if mysqlType, err := test.registry.mysqlType(ReflectType(reflect.TypeOf(test.value)), ""); err != nil || mysqlType != test.expected {
+			t.Errorf("%T: expected %s, got %s (%v)", test.value, test.expected, mysqlType, err)
+		}
+	}
+	if mapping := registry.lookup(ReflectType(reflect.TypeOf(time.Duration(0)))); mapping == nil || mapping.ScanType != "float64" {
+		t.Errorf("expected the mapping of time.Duration, got %+v", mapping)
+	}
+}
+
+func TestTypeRegistryRepositories(t *testing.T) {
+	fset, file, models := sourceModels(t, registryModels, "Job")
+	generated := &strings.Builder{}
+	config := GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", SqlStream: &strings.Builder{}, GoStream: generated, Types: durationRegistry()}
+	if err := GenerateTypes(config, models); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if !strings.Contains(generated.String(), ".Seconds()") {
+		t.Errorf("expected the repositories to encode durations with the mapping, got\n%s", generated.String())
+	}
+	checkGeneratedGo(t, fset, file, generated.String())
+}
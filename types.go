@@ -0,0 +1,88 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"reflect"
+)
+
+// TypeInfo is the subset of reflect.Type used to build a schema.
+// It is implemented for runtime types by ReflectType and for type-checked source code by SourceType.
+type TypeInfo interface {
+	Name() string
+	PkgPath() string
+	String() string
+	Kind() reflect.Kind
+	Elem() TypeInfo
+	Key() TypeInfo
+	Len() int
+	NumField() int
+	Field(i int) FieldInfo
+
+	// EnumValues returns the values allowed for a named string type, or nil if the type is not an enum.
+	EnumValues() []string
+
+	// HasMethod reports whether the type or a pointer to it has the exported method name.
+	HasMethod(name string) bool
+}
+
+// FieldInfo is the subset of reflect.StructField used to build a schema.
+type FieldInfo struct {
+	Name      string
+	Type      TypeInfo
+	Tag       reflect.StructTag
+	Anonymous bool   // whether the field is embedded
+	Doc       string // doc comment of the field in the source code, if known
+	Position  string // position of the declaration in the source code, if known
+}
+
+func ReflectType(typ reflect.Type) TypeInfo {
+	return reflectType{typ}
+}
+
+type reflectType struct {
+	reflect.Type
+}
+
+func (typ reflectType) Elem() TypeInfo {
+	return reflectType{typ.Type.Elem()}
+}
+
+func (typ reflectType) Key() TypeInfo {
+	return reflectType{typ.Type.Key()}
+}
+
+func (typ reflectType) Field(i int) FieldInfo {
+	field := typ.Type.Field(i)
+	return FieldInfo{
+		Name:      field.Name,
+		Type:      reflectType{field.Type},
+		Tag:       field.Tag,
+		Anonymous: field.Anonymous,
+	}
+}
+
+func (typ reflectType) EnumValues() []string {
+	return registeredEnumValues(typ.Type)
+}
+
+func (typ reflectType) HasMethod(name string) bool {
+	_, exists := reflect.PtrTo(typ.Type).MethodByName(name)
+	return exists
+}
@@ -0,0 +1,68 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RegisterColumnType declares the MySQL type of the columns storing a type implementing driver.Valuer and sql.Scanner,
+// such as "BINARY(16)", for the fields of the type without the sqlType tag.
+//
+// Types are identified by their package path and name, so the registration also applies to models loaded from source code
+// by a program calling this function before generating.
+// Use GeneratorConfig.Types to register types that do not implement driver.Valuer and sql.Scanner.
+func RegisterColumnType(typ reflect.Type, mysqlType string) {
+	defaultTypes.RegisterType(typ, TypeMapping{MysqlType: mysqlType})
+}
+
+// isValuer reports whether the type converts itself from and to the database with driver.Valuer and sql.Scanner.
+func isValuer(typ TypeInfo) bool {
+	return typ.HasMethod("Value") && typ.HasMethod("Scan")
+}
+
+// nullWrappedType returns the type wrapped by a nullable type of database/sql, such as string for sql.NullString
+// or T for sql.Null[T], or nil if typ is not such a type.
+func nullWrappedType(typ TypeInfo) TypeInfo {
+	if typ.Kind() != reflect.Struct || typ.PkgPath() != "database/sql" || !strings.HasPrefix(typ.Name(), "Null") || typ.NumField() != 2 {
+		return nil
+	}
+	return typ.Field(0).Type
+}
+
+// valuerMysqlType returns the type of a field converting itself with driver.Valuer and sql.Scanner,
+// which is given by its sqlType tag.
+// It returns an empty string if the field has no sqlType tag.
+func valuerMysqlType(typ TypeInfo, tag reflect.StructTag) (string, error) {
+	declaration, exists := tag.Lookup("sqlType")
+	if !exists {
+		return "", nil
+	}
+	if !isValuer(typ) {
+		return "", errors.New("sqlType fields must implement driver.Valuer and sql.Scanner")
+	}
+	mysqlType, err := parseMysqlType(declaration)
+	if err != nil {
+		return "", fmt.Errorf("sqlType: %v", err)
+	}
+	return mysqlType, nil
+}
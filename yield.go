@@ -21,29 +21,48 @@ package myModel
 import (
 	"errors"
 	"fmt"
+	"github.com/hoop33/go-elvis"
 	"reflect"
 	"strings"
+	"unicode/utf8"
 )
 
-func (schema *Schema) yieldTable(table *MainTable) error {
+// yieldTable computes the fields, keys and edges of table from its model.
+// Problems are reported to schema.diagnostics, and the offending fields are skipped.
+func (schema *Schema) yieldTable(table *MainTable) {
 	table.yielded = true
 
 	if table.Type.Kind() != reflect.Struct {
-		return errors.New("invalid table type: not a struct: " + fmt.Sprintf("%v", table.Type))
+		schema.report(SeverityError, table, "invalid table type: %s is not a struct", table.Type)
+		return
 	}
 
-	for i := 0; i < table.Type.NumField(); i++ {
-		field := table.Type.Field(i)
+	if table.Type.HasMethod("TableOptions") {
+		options, err := methodTableOptions(table.Type)
+		if _, fromSource := table.Type.(sourceType); err != nil && fromSource {
+			schema.report(SeverityWarning, table, "%v; the method is ignored, use a marker field instead", err)
+		} else if err != nil {
+			schema.report(SeverityError, table, "%v", err)
+		}
+		table.Options = options
+	}
 
-		if strings.IndexRune(field.Name, '_') != -1 {
-			return errors.New("field names must not contain underscores to prevent collision with generated columns")
+	columns := map[string]bool{}
+	for _, field := range schema.modelFields(table, table.Type, "", "") {
+		if columns[field.column] {
+			schema.reportField(SeverityError, table, field.Name, "", "the column %s is declared more than once; use the prefix tag on embedded structs or the column tag to rename the fields", field.column)
+			continue
 		}
+		columns[field.column] = true
 
 		tag := field.Tag
 		fieldType := field.Type
 
+		_, isJson := tag.Lookup("jsonColumn")
+		_, isEnum := tag.Lookup("enum")
 		isSlice := false
-		if fieldType.Kind() == reflect.Slice {
+		// byte slices and JSON documents are stored in a single column, unless the bytes are enum values
+		if fieldType.Kind() == reflect.Slice && (!isBytes(fieldType) || isEnum) && !isJson {
 			isSlice = true
 			fieldType = fieldType.Elem()
 		}
@@ -54,23 +73,67 @@ func (schema *Schema) yieldTable(table *MainTable) error {
 			fieldType = fieldType.Elem()
 		}
 
-		isComplex := !isSimpleStruct(fieldType)
+		_, isDecimal := tag.Lookup("decimal")
+		isComplex := !schema.types.isSimple(fieldType) && !isDecimal && !isJson
+		if isComplex && (fieldType.Kind() == reflect.Map || fieldType.Kind() == reflect.Interface) {
+			schema.reportField(SeverityError, table, field.Name, "jsonColumn", "maps and interfaces can only be stored in JSON columns; add the jsonColumn tag")
+			continue
+		}
+		if field.prefixed && isComplex {
+			schema.reportField(SeverityError, table, field.Name, "prefix", "embedded structs with a prefix can only contain simple fields")
+			continue
+		}
+
+		isSelf := isComplex && schema.tableName(fieldType) == table.Name
+		onDelete, onUpdate, ok := schema.edgeReferenceOptions(table, field.Name, tag, isComplex, isPointer)
+		if !ok {
+			continue
+		}
 
-		if _, exists := tag.Lookup("parent"); exists {
+		_, isParent := tag.Lookup("parent")
+		inverse, hasInverse := tag.Lookup("inverse")
+		if hasInverse && !(isComplex && (isParent || !isPointer || isSlice)) {
+			schema.reportField(SeverityError, table, field.Name, "inverse", "the inverse tag is only supported on parent pointers, contained models and slices of pointers")
+			continue
+		}
+		_, nullable := tag.Lookup("nullable")
+		if nullable && !(isComplex && isPointer && !isSlice && !isParent) {
+			schema.reportField(SeverityError, table, field.Name, "nullable", "the nullable tag is only supported on pointers to models; simple fields are nullable if they are pointers")
+			continue
+		}
+		if hasInverse && inverse == "" {
+			schema.reportField(SeverityError, table, field.Name, "inverse", "the inverse tag must name the field of %s on the other side of the edge", fieldType.Name())
+			continue
+		}
+
+		if isParent {
 			// parent reference; the other type must contain this type directly or as a non-pointer slice
 			if !(isPointer && !isSlice && isComplex) {
-				return errors.New("parent column must be a pointer to a non-slice complex type")
+				schema.reportField(SeverityError, table, field.Name, "parent", "parent column must be a pointer to a non-slice complex type")
+				continue
+			}
+			if isSelf {
+				schema.reportField(SeverityError, table, field.Name, "parent", "a model cannot be its own parent; reference the parent row with a plain pointer")
+				continue
 			}
 			table.Edges = append(table.Edges, &Edge{
 				Name:      field.Name,
-				PeerTable: fieldType.Name(),
+				PeerTable: schema.tableName(fieldType),
 				Type:      EdgeTypeUnknownParent,
+				column:    field.column,
+				inverse:   inverse,
+				onDelete:  onDelete,
+				onUpdate:  onUpdate,
 			})
 
-			keys := schema.mustGetTable(fieldType.Name()).PrimaryKeys
+			parent := schema.getTable(fieldType)
+			if !parent.yielded {
+				schema.yieldTable(parent) // the primary keys of the parent are required now
+			}
+			keys := parent.PrimaryKeys
 			renamedKeys := make([]string, 0, len(keys))
 			for _, key := range keys {
-				renamedKeys = append(renamedKeys, fieldType.Name()+"_"+key)
+				renamedKeys = append(renamedKeys, schema.modelColumn(parent)+"_"+key)
 			}
 			if _, exists := tag.Lookup("primaryKey"); exists {
 				table.PrimaryKeys = append(table.PrimaryKeys, renamedKeys...)
@@ -86,61 +149,165 @@ func (schema *Schema) yieldTable(table *MainTable) error {
 				table.CompositeKeys[indexName] = append(table.CompositeKeys[indexName], renamedKeys...)
 			}
 		} else if isComplex && isPointer {
+			for _, key := range [...]string{"primaryKey", "unique", "composite"} {
+				if _, exists := tag.Lookup(key); exists {
+					schema.reportField(SeverityWarning, table, field.Name, key, "indices on references are only supported together with the parent tag")
+				}
+			}
 			if isSlice {
 				// multi-multi edge, create an anonymous table for storing edges
 				table.Edges = append(table.Edges, &Edge{
 					Name:      field.Name,
-					PeerTable: fieldType.Name(),
+					PeerTable: schema.tableName(fieldType),
 					Type:      EdgeTypeMultiMulti,
+					column:    field.column,
+					inverse:   inverse,
+					onDelete:  onDelete,
+					onUpdate:  onUpdate,
 				})
 				schema.getTable(fieldType)
 			} else {
-				// multi-one edge, an ON DELETE SET NULL foreign key on the other type from this type
+				// multi-one edge, a foreign key on the other type from this type
 				table.Edges = append(table.Edges, &Edge{
 					Name:      field.Name,
-					PeerTable: fieldType.Name(),
+					PeerTable: schema.tableName(fieldType),
 					Type:      EdgeTypeMultiOne,
+					column:    field.column,
+					onDelete:  onDelete,
+					onUpdate:  onUpdate,
+					nullable:  nullable,
 				})
 				schema.getTable(fieldType)
 			}
 		} else if isComplex { // !isPointer
+			if isSelf {
+				schema.reportField(SeverityError, table, field.Name, "", "a model cannot contain itself; reference the rows of the same model with pointers")
+				continue
+			}
 			childTable := schema.getTable(fieldType)
-			if childTable.knownParent != nil {
-				return errors.New("there can be only one field (parent) among all types containing another type (child) without pointers; " +
-					"both " + childTable.knownParent.Name + " and " + table.Name + " contain " + fieldType.Name())
+			if childTable.knownParent != nil && childTable.knownParent != table {
+				schema.reportField(SeverityError, table, field.Name, "",
+					"there can be only one type (parent) containing another type (child) without pointers; both %s and %s contain %s",
+					childTable.knownParent.Type.Name(), table.Type.Name(), fieldType.Name())
+				continue
 			}
-			schema.getTable(fieldType).knownParent = table
+			childTable.knownParent = table
+			_, ordered := tag.Lookup("ordered")
 			if isSlice {
 				// one-multi edge, this type is parent of the other type
 				table.Edges = append(table.Edges, &Edge{
 					Name:      field.Name,
-					PeerTable: fieldType.Name(),
+					PeerTable: schema.tableName(fieldType),
 					Type:      EdgeTypeOneMulti,
+					column:    field.column,
+					inverse:   inverse,
+					ordered:   ordered,
 				})
 			} else {
+				if ordered {
+					schema.reportField(SeverityError, table, field.Name, "ordered", "the ordered tag is only supported on slices")
+					continue
+				}
 				// one-one edge, this type is parent of the other type
 				table.Edges = append(table.Edges, &Edge{
 					Name:      field.Name,
-					PeerTable: fieldType.Name(),
+					PeerTable: schema.tableName(fieldType),
 					Type:      EdgeTypeOneOne,
+					column:    field.column,
+					inverse:   inverse,
 				})
 			}
-		} else if isSlice { // !isPointer && !isComplex
-			// create an anonymous table that contain values in this type
-			// TODO AuxTables
 		} else {
-			mysqlType, err := SimpleToMysqlType(fieldType, tag)
+			values, err := enumValues(fieldType, tag)
 			if err != nil {
-				return errors.New(err.Error() + " in " + table.Type.Name() + "." + field.Name)
+				schema.reportField(SeverityError, table, field.Name, "enum", "%v", err)
+				continue
+			}
+			var mysqlType string
+			if isSlice && !isPointer && values != nil {
+				mysqlType, err = enumMysqlType("SET", values) // a slice of enum values is stored as a set
+			} else {
+				mysqlType, err = schema.types.mysqlType(fieldType, tag)
+			}
+			if err != nil {
+				schema.reportField(SeverityError, table, field.Name, "", "%v", err)
+				continue
+			}
+			mapping := schema.types.fieldMapping(fieldType, tag)
+			if mapping != nil && mapping.Decode != "" && mapping.ScanType == "" {
+				schema.reportField(SeverityError, table, field.Name, "", "the mapping of %s has Decode but no ScanType", fieldType)
+				continue
 			}
+
+			if mysqlType == "JSON" && hasKeyTag(tag) {
+				schema.reportField(SeverityError, table, field.Name, "", "JSON columns cannot be keys; use the jsonIndex tag to index paths in the document")
+				continue
+			}
+
+			if isSlice && !isSetType(mysqlType) {
+				if field.prefixed {
+					schema.reportField(SeverityError, table, field.Name, "prefix", "embedded structs with a prefix can only contain simple fields")
+					continue
+				}
+				// create an auxiliary table that contains the values in this field, see computeEdges
+				goType, goImport := goTypeName(field.Type.Elem(), table.Type.PkgPath())
+				_, ordered := tag.Lookup("ordered")
+				table.ValueLists = append(table.ValueLists, &ValueList{
+					Name:   field.Name,
+					column: field.column,
+					Value: &MysqlField{
+						Name:      schema.naming.ColumnName(ValueListValueColumn),
+						Type:      mysqlType,
+						Nullable:  isPointer || nullWrappedType(fieldType) != nil || mapping != nil && mapping.Nullable,
+						Values:    values,
+						GoType:    goType,
+						GoImport:  goImport,
+						GoValuer:  usesValuer(fieldType, tag),
+						GoOrdinal: values != nil && isOrdinalEnum(fieldType),
+						GoMapping: mapping,
+					},
+					Ordered: ordered,
+				})
+				continue
+			}
+
+			goType, goImport := goTypeName(field.Type, table.Type.PkgPath())
 			field := &MysqlField{
-				Name: field.Name,
-				Type: mysqlType,
+				Name:      field.column,
+				Type:      mysqlType,
+				Values:    values,
+				GoName:    field.selector,
+				GoType:    goType,
+				GoImport:  goImport,
+				GoValuer:  usesValuer(fieldType, tag),
+				GoOrdinal: values != nil && isOrdinalEnum(fieldType),
+				GoMapping: mapping,
+				Comment:   columnComment(field.FieldInfo),
+			}
+			if utf8.RuneCountInString(field.Comment) > maxColumnCommentLength {
+				schema.reportField(SeverityError, table, field.GoName, "comment", "column comments cannot be longer than %d characters", maxColumnCommentLength)
+				continue
+			}
+			field.Nullable = isPointer || nullWrappedType(fieldType) != nil || mapping != nil && mapping.Nullable
+			if value, exists := tag.Lookup("default"); exists {
+				if field.Default, err = tagDefault(field, value); err != nil {
+					schema.reportField(SeverityError, table, field.GoName, "default", "%v", err)
+					continue
+				}
+			}
+			if value, exists := tag.Lookup("onUpdate"); exists {
+				if field.OnUpdate, err = columnOnUpdate(field, value); err != nil {
+					schema.reportField(SeverityError, table, field.GoName, "onUpdate", "%v", err)
+					continue
+				}
 			}
-			field.Nullable = isPointer
 			if _, exists := tag.Lookup("primaryKey"); exists {
 				table.PrimaryKeys = append(table.PrimaryKeys, field.Name)
 				if _, exists := tag.Lookup("autoIncrement"); exists {
+					if field.Default != "" {
+						schema.reportField(SeverityError, table, field.GoName, "default", "auto increment columns cannot have a default")
+						continue
+					}
 					field.AutoIncrement = true
 				}
 			} else if indexName, exists := tag.Lookup("unique"); exists {
@@ -155,12 +322,123 @@ func (schema *Schema) yieldTable(table *MainTable) error {
 				table.CompositeKeys[indexName] = append(table.CompositeKeys[indexName], field.Name)
 			}
 			table.SimpleFields = append(table.SimpleFields, field)
+
+			if indexTag, exists := tag.Lookup("jsonIndex"); exists {
+				if mysqlType != "JSON" {
+					schema.reportField(SeverityError, table, field.GoName, "jsonIndex", "only JSON columns can have generated columns")
+					continue
+				}
+				generated, err := jsonIndexFields(field.Name, indexTag)
+				if err != nil {
+					schema.reportField(SeverityError, table, field.GoName, "jsonIndex", "%v", err)
+					continue
+				}
+				for _, generatedField := range generated {
+					table.SimpleFields = append(table.SimpleFields, generatedField)
+					table.CompositeKeys[generatedField.Name] = []string{generatedField.Name}
+				}
+			}
 		}
 	}
-	return nil
+
+	if err := table.Options.check(); err != nil {
+		schema.report(SeverityError, table, "%v", err)
+	}
+}
+
+// modelField is a field of a model, or a field promoted from a struct embedded in the model.
+type modelField struct {
+	FieldInfo        // Name starts with the prefixes of the embedding fields
+	column    string // name of the column, from the column tag or the naming strategy
+	selector  string // selector of the field relative to the model
+	prefixed  bool   // whether Name differs from the promoted name of the field
 }
 
-func isSimpleStruct(p reflect.Type) bool {
+// modelFields returns the fields of typ, replacing embedded structs with their fields, which are prefixed with their prefix tag.
+// Edges and value lists are named after the promoted field, so they cannot be in prefixed structs.
+func (schema *Schema) modelFields(table *MainTable, typ TypeInfo, prefix string, selectorPrefix string) []modelField {
+	fields := make([]modelField, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		field := modelField{FieldInfo: typ.Field(i), selector: selectorPrefix + typ.Field(i).Name, prefixed: prefix != ""}
+		field.Name = prefix + field.Name
+		table.fieldPositions[field.Name] = field.Position
+		table.fieldPositions[field.selector] = field.Position
+
+		if typ.Field(i).Name == "_" {
+			if selectorPrefix != "" {
+				schema.reportField(SeverityError, table, field.Name, "", "table options must be declared in the model, not in embedded structs")
+			} else {
+				table.Options = table.Options.override(tagTableOptions(field.Tag))
+			}
+			continue
+		}
+
+		if strings.IndexRune(field.Name, '_') != -1 {
+			schema.reportField(SeverityError, table, field.Name, "", "field names must not contain underscores to prevent collision with generated columns")
+			continue
+		}
+
+		field.column = schema.naming.ColumnName(field.Name)
+		if column, exists := field.Tag.Lookup("column"); exists {
+			if column == "" {
+				schema.reportField(SeverityError, table, field.Name, "column", "the column name cannot be empty")
+				continue
+			}
+			field.column = column
+		}
+
+		_, isJson := field.Tag.Lookup("jsonColumn")
+		if !field.Anonymous || isJson || schema.types.isSimple(field.Type) {
+			fields = append(fields, field)
+			continue
+		}
+		if field.Type.Kind() == reflect.Ptr {
+			schema.reportField(SeverityError, table, field.Name, "", "embedded pointers are not supported, because the fields would be NULL when the pointer is nil")
+			continue
+		}
+		if field.Type.Kind() != reflect.Struct {
+			fields = append(fields, field)
+			continue
+		}
+		embeddedPrefix, _ := field.Tag.Lookup("prefix")
+		fields = append(fields, schema.modelFields(table, field.Type, prefix+embeddedPrefix, field.selector+".")...)
+	}
+	return fields
+}
+
+// goTypeName returns how typ is spelt in the package pkgPath, and the import it requires.
+func goTypeName(typ TypeInfo, pkgPath string) (string, string) {
+	if typ.Kind() == reflect.Ptr {
+		name, importPath := goTypeName(typ.Elem(), pkgPath)
+		return "*" + name, importPath
+	}
+	if typ.Kind() == reflect.Slice && typ.Name() == "" {
+		name, importPath := goTypeName(typ.Elem(), pkgPath)
+		return "[]" + name, importPath
+	}
+	if typ.Kind() == reflect.Array && typ.Name() == "" {
+		name, importPath := goTypeName(typ.Elem(), pkgPath)
+		return fmt.Sprintf("[%d]%s", typ.Len(), name), importPath
+	}
+	if typ.Kind() == reflect.Map && typ.Name() == "" {
+		keyName, keyImport := goTypeName(typ.Key(), pkgPath)
+		name, importPath := goTypeName(typ.Elem(), pkgPath)
+		return "map[" + keyName + "]" + name, elvis.Ternary(importPath == "", keyImport, importPath).(string)
+	}
+	if typ.Kind() == reflect.Interface && typ.Name() == "" {
+		return "interface{}", "" // methods of anonymous interfaces are not supported
+	}
+	if typ.PkgPath() == "" || typ.PkgPath() == pkgPath {
+		return typ.Name(), ""
+	}
+	return typ.String(), typ.PkgPath()
+}
+
+// isSimple reports whether a field of type p is stored in a single column.
+func (registry *TypeRegistry) isSimple(p TypeInfo) bool {
+	if registry.lookup(p) != nil {
+		return true
+	}
 	switch p.Kind() {
 	case reflect.Bool:
 		return true
@@ -186,13 +464,75 @@ func isSimpleStruct(p reflect.Type) bool {
 		return true
 	case reflect.String:
 		return true
+	case reflect.Slice, reflect.Array:
+		return isBytes(p)
 	case reflect.Struct:
-		return p.PkgPath() == "time" && p.Name() == "Time"
+		return nullWrappedType(p) != nil || isValuer(p)
+	}
+	return false
+}
+
+// usesValuer reports whether a field is written and scanned with its own driver.Valuer and sql.Scanner
+// rather than the conversions generated for its column type.
+func usesValuer(typ TypeInfo, tag reflect.StructTag) bool {
+	_, exists := tag.Lookup("sqlType")
+	return exists || nullWrappedType(typ) != nil
+}
+
+// hasKeyTag reports whether a field declares a primary, unique or composite key.
+func hasKeyTag(tag reflect.StructTag) bool {
+	for _, key := range [...]string{"primaryKey", "unique", "composite"} {
+		if _, exists := tag.Lookup(key); exists {
+			return true
+		}
 	}
 	return false
 }
 
-func SimpleToMysqlType(typ reflect.Type, tag reflect.StructTag) (string, error) {
+// isBytes reports whether typ is a byte slice or a byte array, which are stored in binary columns.
+func isBytes(typ TypeInfo) bool {
+	return (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) && typ.Elem().Kind() == reflect.Uint8
+}
+
+// SimpleToMysqlType returns the type of the column storing a simple field,
+// using the built-in mappings and those registered with RegisterColumnType.
+func SimpleToMysqlType(typ TypeInfo, tag reflect.StructTag) (string, error) {
+	return (*TypeRegistry)(nil).mysqlType(typ, tag)
+}
+
+// fieldMapping returns the mapping of a field of type typ, or nil if it is not mapped or the tags of the field determine its column.
+func (registry *TypeRegistry) fieldMapping(typ TypeInfo, tag reflect.StructTag) *TypeMapping {
+	for _, key := range [...]string{"decimal", "jsonColumn", "sqlType"} {
+		if _, exists := tag.Lookup(key); exists {
+			return nil
+		}
+	}
+	return registry.lookup(typ)
+}
+
+func (registry *TypeRegistry) mysqlType(typ TypeInfo, tag reflect.StructTag) (string, error) {
+	if precision, exists := tag.Lookup("decimal"); exists {
+		return decimalMysqlType(typ, precision)
+	}
+	if _, exists := tag.Lookup("jsonColumn"); exists {
+		return "JSON", nil
+	}
+	if mysqlType, err := valuerMysqlType(typ, tag); err != nil || mysqlType != "" {
+		return mysqlType, err
+	}
+	if values, err := enumValues(typ, tag); err != nil {
+		return "", err
+	} else if values != nil {
+		return enumMysqlType("ENUM", values)
+	}
+	if mapping := registry.lookup(typ); mapping != nil {
+		mysqlType, err := parseMysqlType(mapping.MysqlType)
+		if err != nil {
+			return "", fmt.Errorf("mapping of %s: %v", typ, err)
+		}
+		return mysqlType, nil
+	}
+
 	switch typ.Kind() {
 	case reflect.Bool:
 		return "BOOL", nil
@@ -245,10 +585,65 @@ func SimpleToMysqlType(typ reflect.Type, tag reflect.StructTag) (string, error)
 
 		return "", errors.New("string declaration must either have the width tag")
 
+	case reflect.Slice:
+		if !isBytes(typ) {
+			break
+		}
+		if value, ok := tag.Lookup("width"); ok {
+			return "VARBINARY(" + value + ")", nil
+		}
+		if blobType, ok := tag.Lookup("blob"); ok {
+			switch blobType {
+			case "tiny":
+				return "TINYBLOB", nil
+			case "small":
+				return "BLOB", nil
+			case "":
+				return "BLOB", nil
+			case "medium":
+				return "MEDIUMBLOB", nil
+			case "long":
+				return "LONGBLOB", nil
+			}
+			return "", errors.New("unknown blob type")
+		}
+		return "", errors.New("byte slice declaration must either have the width or the blob tag")
+
+	case reflect.Array:
+		if isBytes(typ) {
+			if typ.Len() < 1 || typ.Len() > 255 {
+				return "", fmt.Errorf("byte arrays must have 1 to 255 bytes, got %d", typ.Len())
+			}
+			return fmt.Sprintf("BINARY(%d)", typ.Len()), nil
+		}
+
+	case reflect.Map, reflect.Interface:
+		return "", errors.New("maps and interfaces must be stored in JSON columns with the jsonColumn tag")
+
 	case reflect.Struct:
-		if typ.PkgPath() == "time" && typ.Name() == "Time" {
-			return "TIMESTAMP", nil
+		if wrapped := nullWrappedType(typ); wrapped != nil {
+			return registry.mysqlType(wrapped, tag)
+		}
+		if isValuer(typ) {
+			return "", fmt.Errorf("the column type of %s must be declared with the sqlType tag or RegisterColumnType", typ)
 		}
 	}
 	return "", errors.New(fmt.Sprintf("unknown type %v", typ.Kind()))
 }
+
+// decimalMysqlType returns the type of a field with the decimal tag, which contains the precision and the scale of the column.
+// Floats are not allowed since they cannot hold the values exactly;
+// the field must be a string, or a type converting itself from and to the database such as a decimal library type.
+func decimalMysqlType(typ TypeInfo, precision string) (string, error) {
+	if typ.Kind() != reflect.String && !isValuer(typ) {
+		return "", errors.New("decimal fields must be strings or implement driver.Valuer and sql.Scanner")
+	}
+	var digits, scale int
+	if _, err := fmt.Sscanf(precision, "%d,%d", &digits, &scale); err != nil {
+		return "", fmt.Errorf("decimal tag must be \"precision,scale\", got %q", precision)
+	}
+	if digits < 1 || digits > 65 || scale < 0 || scale > 30 || scale > digits {
+		return "", fmt.Errorf("invalid decimal precision %d and scale %d", digits, scale)
+	}
+	return fmt.Sprintf("DECIMAL(%d,%d)", digits, scale), nil
+}
@@ -0,0 +1,187 @@
+/*
+ * Poggit
+ *
+ * Copyright (C) 2018 Poggit
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package myModel
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// columnTypeTests are the column types of simple fields, or the errors for fields that cannot be stored.
+var columnTypeTests = []struct {
+	value    interface{} // a value of the type of the field
+	tag      reflect.StructTag
+	expected string // empty if an error is expected
+}{
+	{"", `decimal:"12,2"`, "DECIMAL(12,2)"},
+	{"", `decimal:"65,30"`, "DECIMAL(65,30)"},
+	{"", `decimal:"66,2"`, ""},
+	{"", `decimal:"12"`, ""},
+	{"", `decimal:"2,3"`, ""},
+	{float64(0), `decimal:"12,2"`, ""},
+
+	{[]byte(nil), `width:"32"`, "VARBINARY(32)"},
+	{[]byte(nil), `blob:""`, "BLOB"},
+	{[]byte(nil), `blob:"medium"`, "MEDIUMBLOB"},
+	{[]byte(nil), ``, ""},
+	{[16]byte{}, ``, "BINARY(16)"},
+	{[0]byte{}, ``, ""},
+
+	{map[string]interface{}{}, `jsonColumn:""`, "JSON"},
+	{map[string]interface{}{}, ``, ""},
+	{map[string]interface{}{}, `json:"attrs"`, ""},
+	{"", `json:"name" width:"32"`, "VARCHAR(32)"},
+	{[]interface{}{}, `jsonColumn:""`, "JSON"},
+	{struct{ A int32 }{}, `jsonColumn:""`, "JSON"},
+
+	{sql.NullString{}, `width:"32"`, "VARCHAR(32)"},
+	{sql.NullInt64{}, ``, "BIGINT SIGNED"},
+	{sql.NullTime{}, ``, "TIMESTAMP"},
+	{sql.Null[bool]{}, ``, "BOOL"},
+	{valuerPoint{}, `sqlType:"VARCHAR(64)"`, "VARCHAR(64)"},
+	{valuerPoint{}, `decimal:"10,2"`, "DECIMAL(10,2)"},
+	{valuerPoint{}, ``, ""},
+	{struct{ A int32 }{}, `sqlType:"VARCHAR(64)"`, ""},
+}
+
+// valuerPoint is a type converting itself from and to the database.
+type valuerPoint struct{ X, Y int32 }
+
+func (point valuerPoint) Value() (driver.Value, error) {
+	return fmt.Sprintf("%d,%d", point.X, point.Y), nil
+}
+
+func (point *valuerPoint) Scan(src interface{}) error {
+	_, err := fmt.Sscanf(fmt.Sprint(src), "%d,%d", &point.X, &point.Y)
+	return err
+}
+
+func TestColumnTypes(t *testing.T) {
+	for _, test := range columnTypeTests {
+		mysqlType, err := SimpleToMysqlType(ReflectType(reflect.TypeOf(test.value)), test.tag)
+		if test.expected == "" && err == nil {
+			t.Errorf("%T `%s`: expected an error, got %s", test.value, test.tag, mysqlType)
+		} else if test.expected != "" && mysqlType != test.expected {
+			t.Errorf("%T `%s`: expected %s, got %s (%v)", test.value, test.tag, test.expected, mysqlType, err)
+		}
+	}
+}
+
+func TestJsonIndexFields(t *testing.T) {
+	fields, err := jsonIndexFields("Attrs", "email $.email VARCHAR(128); age $.user.ages[0] INT UNSIGNED")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 2 || fields[0].Name != "Attrs_email" || fields[0].Type != "VARCHAR(128)" || fields[1].Name != "Attrs_age" || fields[1].JsonPath.Path != "$.user.ages[0]" {
+		t.Errorf("unexpected generated columns %+v", fields)
+	}
+	if clause := mysqlGeneratedClause(fields[1]); clause != "GENERATED ALWAYS AS (`Attrs`->'$.user.ages[0]') VIRTUAL" {
+		t.Errorf("unexpected generated clause %s", clause)
+	}
+	if elements := jsonPathElements("$.user.ages[0]"); !reflect.DeepEqual(elements, []string{"user", "ages", "0"}) {
+		t.Errorf("unexpected path elements %q", elements)
+	}
+
+	for _, tag := range []string{"email $.email", "e-mail $.email VARCHAR(128)", "email $..email VARCHAR(128)", "email $.email TEXT"} {
+		if _, err := jsonIndexFields("Attrs", tag); err == nil {
+			t.Errorf("expected jsonIndex %q to be rejected", tag)
+		}
+	}
+}
+
+const jsonModels = `package models
+
+type Profile struct {
+	Id    uint32                 ` + "`primaryKey:\"\" autoIncrement:\"\" json:\"id\"`" + `
+	Name  string                 ` + "`width:\"32\" json:\"name\"`" + `
+	Token string                 ` + "`width:\"32\" json:\"-\"`" + `
+	Attrs map[string]interface{} ` + "`jsonColumn:\"\" json:\"attrs\"`" + `
+}
+`
+
+func TestJsonColumns(t *testing.T) {
+	_, _, models := sourceModels(t, jsonModels, "Profile")
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	if field := schema.Tables["Profile"].FindField("Attrs"); field == nil || field.Type != "JSON" {
+		t.Errorf("expected Attrs to be stored in a JSON column, got %+v", field)
+	}
+	for _, name := range []string{"Name", "Token"} {
+		if field := schema.Tables["Profile"].FindField(name); field == nil || field.Type != "VARCHAR(32)" {
+			t.Errorf("expected the json tag of encoding/json to keep %s a VARCHAR column, got %+v", name, field)
+		}
+	}
+
+	_, _, models = sourceModels(t, strings.Replace(jsonModels, "jsonColumn:\"\" ", "", 1), "Profile")
+	_, err = BuildSchemaFromTypes(models)
+	if diagnostics, ok := err.(Diagnostics); !ok || len(diagnostics) != 1 || diagnostics[0].Field != "Attrs" || diagnostics[0].Tag != "jsonColumn" {
+		t.Errorf("expected an error about the missing jsonColumn tag of Attrs, got %v", err)
+	}
+}
+
+const embeddedModels = `package models
+
+type Audit struct {
+	Creator  string ` + "`width:\"32\"`" + `
+	Revision uint32
+}
+
+type Address struct {
+	City string ` + "`width:\"32\"`" + `
+	Zip  string ` + "`width:\"10\"`" + `
+}
+
+type Order struct {
+	Id uint32 ` + "`primaryKey:\"\" autoIncrement:\"\"`" + `
+	Audit
+	Address ` + "`prefix:\"Ship\"`" + `
+}
+`
+
+func TestEmbeddedStructs(t *testing.T) {
+	fset, file, models := sourceModels(t, embeddedModels, "Order")
+	schema, err := BuildSchemaFromTypes(models)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	columns := []string{}
+	for _, field := range schema.Tables["Order"].SimpleFields {
+		columns = append(columns, field.Name)
+	}
+	if !stringSlicesEqual(columns, []string{"Id", "Creator", "Revision", "ShipCity", "ShipZip"}) {
+		t.Errorf("expected the fields of the embedded structs to be flattened, got %v", columns)
+	}
+
+	generated := &strings.Builder{}
+	if err := schema.OutputGo(GeneratorConfig{Package: "models", Indent: "\t", Eol: "\n", GoStream: generated}); err != nil {
+		t.Fatalf("output Go: %v", err)
+	}
+	checkGeneratedGo(t, fset, file, generated.String())
+
+	_, _, models = sourceModels(t, strings.Replace(embeddedModels, "\tAudit\n", "\t*Audit\n", 1), "Order")
+	if _, err := BuildSchemaFromTypes(models); err == nil || !strings.Contains(err.Error(), "embedded pointers are not supported") {
+		t.Errorf("expected embedded pointers to be rejected, got %v", err)
+	}
+}